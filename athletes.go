@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+)
+
+// singleAthlete returns the only selected athlete, for commands that work on one
+func singleAthlete(athletes []*Config) *Config {
+	if len(athletes) != 1 {
+		var labels []string
+		for _, a := range athletes {
+			labels = append(labels, a.Label())
+		}
+		log.Fatalf("This command works on one athlete; choose one with --athlete (%s)", strings.Join(labels, ", "))
+	}
+	return athletes[0]
+}
+
+// forEachAthlete calls fn for every athlete, running up to concurrency of them at
+// once. Athletes running side by side get their own progress buffer, written to
+// progress in one piece when they finish so their output doesn't interleave.
+// The athletes' errors are returned together once all of them are done.
+func forEachAthlete(athletes []*Config, concurrency int, progress io.Writer, fn func(i int, a *Config, w io.Writer) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	buffered := concurrency > 1 && len(athletes) > 1
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	errs := make([]error, len(athletes))
+	for i, a := range athletes {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			w := progress
+			var buf bytes.Buffer
+			if buffered {
+				w = &buf
+			}
+			if err := fn(i, a, w); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", a.Label(), err)
+			}
+			if buffered {
+				mu.Lock()
+				progress.Write(buf.Bytes())
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
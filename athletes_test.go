@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestForEachAthlete(t *testing.T) {
+	athletes := []*Config{{AthleteID: "i1"}, {AthleteID: "i2"}, {AthleteID: "i3"}}
+
+	var progress bytes.Buffer
+	err := forEachAthlete(athletes, 3, &progress, func(i int, a *Config, w io.Writer) error {
+		// Write in steps so unbuffered athletes would interleave
+		for step := 1; step <= 3; step++ {
+			fmt.Fprintf(w, "%s step %d\n", a.AthleteID, step)
+			time.Sleep(time.Millisecond)
+		}
+		if a.AthleteID == "i2" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "i2: boom") {
+		t.Errorf("err = %v; want the failing athlete's error", err)
+	}
+	for _, id := range []string{"i1", "i2", "i3"} {
+		block := fmt.Sprintf("%[1]s step 1\n%[1]s step 2\n%[1]s step 3\n", id)
+		if !strings.Contains(progress.String(), block) {
+			t.Errorf("progress = %q; want %s's lines together", progress.String(), id)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+const defaultAuditFile = "audit.jsonl"
+
+// Audit actions
+const (
+	AuditDelete         = "delete"
+	AuditUpdateName     = "update_name"
+	AuditUpdateMetadata = "update_metadata"
+	AuditUpload         = "upload"
+)
+
+// AuditScorecard is a group member's score at the time of a mutation
+type AuditScorecard struct {
+	ID        string             `json:"id"`
+	Total     float64            `json:"total"`
+	Breakdown map[string]float64 `json:"breakdown"`
+}
+
+// AuditEntry records a single attempted mutation
+type AuditEntry struct {
+	Time       time.Time        `json:"time"`
+	ActivityID string           `json:"activity_id"`
+	Action     string           `json:"action"`
+	Before     interface{}      `json:"before,omitempty"`
+	After      interface{}      `json:"after,omitempty"`
+	WinnerID   string           `json:"winner_id,omitempty"`
+	Scorecards []AuditScorecard `json:"scorecards,omitempty"`
+	DryRun     bool             `json:"dry_run"`
+	Result     string           `json:"result"` // "ok", "error" or "dry_run"
+	Error      string           `json:"error,omitempty"`
+}
+
+// AuditLog appends entries to a JSONL file. A nil log records nothing.
+type AuditLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+// AuditFile returns the configured audit log path, or the default
+func (c *Config) AuditFile() string {
+	if c.AuditPath != "" {
+		return c.AuditPath
+	}
+	return defaultAuditFile
+}
+
+func NewAuditLog(path string) *AuditLog {
+	return &AuditLog{path: path}
+}
+
+// Record stamps and appends an entry, deriving Result from the error and dry-run
+// flag. The returned write error is for reporting; it never stops the run.
+func (l *AuditLog) Record(entry AuditEntry, err error) error {
+	if l == nil {
+		return nil
+	}
+
+	entry.Time = time.Now()
+	switch {
+	case entry.DryRun:
+		entry.Result = "dry_run"
+	case err != nil:
+		entry.Result = "error"
+		entry.Error = err.Error()
+	default:
+		entry.Result = "ok"
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return appendJSONLine(l.path, entry)
+}
+
+// auditScorecards snapshots a ranked group's scores for the audit log
+func auditScorecards(group []evaluatedActivity) []AuditScorecard {
+	var cards []AuditScorecard
+	for _, e := range group {
+		cards = append(cards, AuditScorecard{ID: e.Detail.ID, Total: e.Score.Total, Breakdown: e.Score.Breakdown})
+	}
+	return cards
+}
+
+// appendJSONLine appends v as a single JSON line
+func appendJSONLine(path string, v interface{}) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuditLogRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit := NewAuditLog(path)
+
+	audit.Record(AuditEntry{ActivityID: "i1", Action: AuditDelete, DryRun: true}, nil)
+	audit.Record(AuditEntry{ActivityID: "i2", Action: AuditDelete}, errors.New("unexpected status code during deletion: 404"))
+	audit.Record(AuditEntry{ActivityID: "i3", Action: AuditUpdateName, After: map[string]interface{}{"name": "Ellisville - Weldon"}}, nil)
+
+	var nilLog *AuditLog
+	nilLog.Record(AuditEntry{ActivityID: "i4"}, nil) // Must not panic
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open audit log: %v", err)
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid JSON line: %v", err)
+		}
+		entries = append(entries, e)
+	}
+
+	want := []struct {
+		id, result string
+	}{
+		{"i1", "dry_run"},
+		{"i2", "error"},
+		{"i3", "ok"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries; want %d", len(entries), len(want))
+	}
+	for i, w := range want {
+		if entries[i].ActivityID != w.id || entries[i].Result != w.result || entries[i].Time.IsZero() {
+			t.Errorf("entry %d = %+v; want id %s result %s", i, entries[i], w.id, w.result)
+		}
+	}
+	if entries[1].Error == "" {
+		t.Error("expected error message on failed entry")
+	}
+}
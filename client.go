@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 )
 
@@ -28,8 +31,8 @@ func NewIntervalsClient(apiKey, athleteID string) *IntervalsClient {
 }
 
 func (c *IntervalsClient) doRequest(method, path string, body io.Reader) (*http.Response, error) {
-	url := fmt.Sprintf("%s%s", c.BaseURL, path)
-	req, err := http.NewRequest(method, url, body)
+	reqURL := fmt.Sprintf("%s%s", c.BaseURL, path)
+	req, err := http.NewRequest(method, reqURL, body)
 	if err != nil {
 		return nil, err
 	}
@@ -42,6 +45,33 @@ func (c *IntervalsClient) doRequest(method, path string, body io.Reader) (*http.
 	return c.HTTPClient.Do(req)
 }
 
+// Athlete is the profile returned by the athlete endpoint
+type Athlete struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// GetAthlete fetches the athlete's profile, which also verifies the API key
+func (c *IntervalsClient) GetAthlete() (*Athlete, error) {
+	path := fmt.Sprintf("/api/v1/athlete/%s", c.AthleteID)
+	resp, err := c.doRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var athlete Athlete
+	if err := json.NewDecoder(resp.Body).Decode(&athlete); err != nil {
+		return nil, err
+	}
+
+	return &athlete, nil
+}
+
 func (c *IntervalsClient) ListActivities(oldest, newest time.Time) ([]Activity, error) {
 	path := fmt.Sprintf("/api/v1/athlete/%s/activities?oldest=%s&newest=%s",
 		c.AthleteID, oldest.Format("2006-01-02"), newest.Format("2006-01-02"))
@@ -98,6 +128,7 @@ func (c *IntervalsClient) DeleteActivity(id string) error {
 
 	return nil
 }
+
 func (c *IntervalsClient) UpdateActivity(id string, updates map[string]interface{}) error {
 	path := fmt.Sprintf("/api/v1/activity/%s", id)
 	body, err := json.Marshal(updates)
@@ -117,3 +148,71 @@ func (c *IntervalsClient) UpdateActivity(id string, updates map[string]interface
 
 	return nil
 }
+
+// GetStreams fetches the activity's streams, limited to the given types if any
+func (c *IntervalsClient) GetStreams(id string, types ...string) ([]Stream, error) {
+	path := fmt.Sprintf("/api/v1/activity/%s/streams", id)
+	if len(types) > 0 {
+		path += "?types=" + url.QueryEscape(strings.Join(types, ","))
+	}
+	resp, err := c.doRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var streams []Stream
+	if err := json.NewDecoder(resp.Body).Decode(&streams); err != nil {
+		return nil, err
+	}
+
+	return streams, nil
+}
+
+// UploadActivity uploads an activity file and returns the ID of the created activity
+func (c *IntervalsClient) UploadActivity(filename string, data []byte, name string) (string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(data); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	path := fmt.Sprintf("/api/v1/athlete/%s/activities?name=%s", c.AthleteID, url.QueryEscape(name))
+	reqURL := fmt.Sprintf("%s%s", c.BaseURL, path)
+	req, err := http.NewRequest("POST", reqURL, &buf)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.SetBasicAuth("API_KEY", c.APIKey)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("unexpected status code during upload: %d", resp.StatusCode)
+	}
+
+	var result struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	return result.ID, nil
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -22,18 +24,112 @@ func LoadConfig(path string) (*Config, error) {
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, err
 	}
+	applyEnv(&config)
 
-	// Override with environment variables if present
+	// Each athlete entry is decoded over a fresh copy of the shared settings, so
+	// it can override any of them (API key, weights, device priorities, ...)
+	var multi struct {
+		Athletes []yaml.Node `yaml:"athletes"`
+	}
+	if err := yaml.Unmarshal(data, &multi); err != nil {
+		return nil, err
+	}
+	for i, node := range multi.Athletes {
+		var athlete Config
+		if err := yaml.Unmarshal(data, &athlete); err != nil {
+			return nil, err
+		}
+		applyEnv(&athlete)
+		athlete.AthleteID, athlete.Name = "", ""
+		if err := node.Decode(&athlete); err != nil {
+			return nil, fmt.Errorf("athletes[%d]: %w", i, err)
+		}
+		if athlete.AthleteID == "" {
+			return nil, fmt.Errorf("athletes[%d]: athlete_id is required", i)
+		}
+		athlete.AuditPath = athletePath(config.AuditFile(), athlete.AuditFile(), athlete.AthleteID)
+		athlete.IgnorePath = athletePath(config.IgnoreFile(), athlete.IgnoreFile(), athlete.AthleteID)
+		athlete.DecisionsPath = athletePath(config.DecisionsFile(), athlete.DecisionsFile(), athlete.AthleteID)
+		athlete.HistoryPath = athletePath(config.HistoryFile(), athlete.HistoryFile(), athlete.AthleteID)
+		athlete.Watch.StateFile = athletePath(config.Watch.StatePath(), athlete.Watch.StatePath(), athlete.AthleteID)
+		if err := athlete.validate(); err != nil {
+			return nil, fmt.Errorf("athlete %s: %w", athlete.Label(), err)
+		}
+		config.Athletes = append(config.Athletes, &athlete)
+	}
+	if len(config.Athletes) > 0 {
+		return &config, nil
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// athletePath gives an athlete its own copy of a state file it would otherwise
+// share with the other athletes, e.g. audit.jsonl -> audit.i12345.jsonl. A path
+// set in the athlete's own entry is kept.
+func athletePath(shared, path, athleteID string) string {
+	if path != shared {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + athleteID + ext
+}
+
+// applyEnv overrides the config with environment variables if present
+func applyEnv(config *Config) {
 	if envKey := os.Getenv("INTERVALS_API_KEY"); envKey != "" {
 		config.APIKey = envKey
 	}
 	if envID := os.Getenv("INTERVALS_ATHLETE_ID"); envID != "" {
 		config.AthleteID = envID
 	}
+}
+
+// validate checks the settings needed to process one athlete
+func (c *Config) validate() error {
+	if c.APIKey == "" || c.AthleteID == "" {
+		return fmt.Errorf("API key or Athlete ID missing from config and environment")
+	}
+	if err := c.Mismatch.Validate(); err != nil {
+		return err
+	}
+	if err := ValidateTieBreakers(c.TieBreakers); err != nil {
+		return err
+	}
+	if _, err := NewProtector(c.Protect); err != nil {
+		return err
+	}
+	if _, err := NewNotifiers(c.Notifications); err != nil {
+		return err
+	}
+	return nil
+}
 
-	if config.APIKey == "" || config.AthleteID == "" {
-		return nil, fmt.Errorf("API key or Athlete ID missing from config and environment")
+// Label names the athlete for output, preferring the configured name
+func (c *Config) Label() string {
+	if c.Name != "" {
+		return fmt.Sprintf("%s (%s)", c.Name, c.AthleteID)
 	}
+	return c.AthleteID
+}
 
-	return &config, nil
+// SelectAthletes returns the per-athlete configs to process. A config without
+// an athletes list is a single athlete. The filter matches an athlete ID or name.
+func (c *Config) SelectAthletes(filter string) ([]*Config, error) {
+	athletes := c.Athletes
+	if len(athletes) == 0 {
+		athletes = []*Config{c}
+	}
+	if filter == "" {
+		return athletes, nil
+	}
+	for _, a := range athletes {
+		if a.AthleteID == filter || strings.EqualFold(a.Name, filter) {
+			return []*Config{a}, nil
+		}
+	}
+	return nil, fmt.Errorf("no athlete %q in config", filter)
 }
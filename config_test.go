@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigAthletes(t *testing.T) {
+	t.Setenv("INTERVALS_API_KEY", "")
+	t.Setenv("INTERVALS_ATHLETE_ID", "")
+	path := filepath.Join(t.TempDir(), "config.yml")
+	data := `api_key: coach-key
+days_to_sync: 14
+device_priority: ["Garmin"]
+concurrency: 2
+athletes:
+  - athlete_id: i1
+    name: Alice
+  - athlete_id: i2
+    api_key: own-key
+    device_priority: ["Wahoo"]
+    audit_log: bob-audit.jsonl
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if len(config.Athletes) != 2 || config.Concurrency != 2 {
+		t.Fatalf("athletes = %d, concurrency = %d; want 2, 2", len(config.Athletes), config.Concurrency)
+	}
+
+	alice, bob := config.Athletes[0], config.Athletes[1]
+	if alice.APIKey != "coach-key" || alice.DaysToSync != 14 || alice.DevicePriority[0] != "Garmin" {
+		t.Errorf("alice = %+v; want the shared settings", alice)
+	}
+	if bob.APIKey != "own-key" || bob.DaysToSync != 14 || bob.DevicePriority[0] != "Wahoo" {
+		t.Errorf("bob = %+v; want overridden key and device priority", bob)
+	}
+	if bob.Name != "" {
+		t.Errorf("bob.Name = %q; names must not leak between athletes", bob.Name)
+	}
+
+	// Shared state files are split per athlete unless the athlete sets its own
+	for _, tt := range []struct{ got, want string }{
+		{alice.AuditFile(), "audit.i1.jsonl"},
+		{alice.IgnoreFile(), "ignore.i1.json"},
+		{alice.DecisionsFile(), "decisions.i1.jsonl"},
+		{alice.HistoryFile(), "history.i1.jsonl"},
+		{alice.Watch.StatePath(), "watch_state.i1.json"},
+		{bob.AuditFile(), "bob-audit.jsonl"},
+		{bob.HistoryFile(), "history.i2.jsonl"},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("state path = %q; want %q", tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfigAthleteMissingID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("api_key: k\nathletes:\n  - name: Alice\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("expected an error for an athlete without athlete_id")
+	}
+}
+
+func TestSelectAthletes(t *testing.T) {
+	single := &Config{AthleteID: "i0"}
+	multi := &Config{Athletes: []*Config{{AthleteID: "i1", Name: "Alice"}, {AthleteID: "i2"}}}
+
+	tests := []struct {
+		name    string
+		config  *Config
+		filter  string
+		want    []string
+		wantErr bool
+	}{
+		{"single", single, "", []string{"i0"}, false},
+		{"all", multi, "", []string{"i1", "i2"}, false},
+		{"by id", multi, "i2", []string{"i2"}, false},
+		{"by name", multi, "alice", []string{"i1"}, false},
+		{"unknown", multi, "i9", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.config.SelectAthletes(tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v; wantErr %v", err, tt.wantErr)
+			}
+			var ids []string
+			for _, a := range got {
+				ids = append(ids, a.AthleteID)
+			}
+			if len(ids) != len(tt.want) {
+				t.Fatalf("got %v; want %v", ids, tt.want)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Errorf("got %v; want %v", ids, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// csvBaseColumns precede one column per score Breakdown component
+var csvBaseColumns = []string{"athlete_id", "group_id", "group_start", "activity_id", "name", "rank", "total_score"}
+
+// csvTrailingColumns follow the Breakdown components
+var csvTrailingColumns = []string{"device", "uploader", "distance_m", "moving_time_s", "plan"}
+
+// breakdownColumns collects every Breakdown component seen in the report, sorted
+func breakdownColumns(groups []*GroupReport) []string {
+	seen := make(map[string]bool)
+	var columns []string
+	for _, g := range groups {
+		for _, m := range g.Members {
+			for name := range m.Breakdown {
+				if !seen[name] {
+					seen[name] = true
+					columns = append(columns, name)
+				}
+			}
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// WriteCSVReport writes one row per member of each duplicate group, across all
+// athletes' runs. Components missing from a member's Breakdown are written as 0.
+func WriteCSVReport(w io.Writer, reports ...*RunReport) error {
+	var groups []*GroupReport
+	for _, r := range reports {
+		groups = append(groups, r.Groups...)
+	}
+	components := breakdownColumns(groups)
+
+	header := append([]string{}, csvBaseColumns...)
+	header = append(header, components...)
+	header = append(header, csvTrailingColumns...)
+
+	out := csv.NewWriter(w)
+	if err := out.Write(header); err != nil {
+		return err
+	}
+
+	for _, g := range groups {
+		for _, m := range g.Members {
+			row := []string{
+				g.AthleteID,
+				g.ID,
+				g.Start.Format("2006-01-02T15:04:05"),
+				m.ID,
+				m.Name,
+				strconv.Itoa(m.Rank),
+				formatFloat(m.Score),
+			}
+			for _, name := range components {
+				row = append(row, formatFloat(m.Breakdown[name]))
+			}
+			row = append(row,
+				m.Device,
+				m.Uploader,
+				formatFloat(m.Distance),
+				strconv.Itoa(m.MovingTime),
+				m.Plan,
+			)
+			if err := out.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// saveCSVReport writes the CSV export to path
+func saveCSVReport(path string, reports []*RunReport) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteCSVReport(f, reports...); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+)
+
+func TestWriteCSVReport(t *testing.T) {
+	report := &RunReport{
+		Groups: []*GroupReport{{
+			AthleteID: "i0",
+			ID:        "i1,i2",
+			WinnerID:  "i1",
+			Members: []*MemberReport{
+				{ID: "i1", Name: "Hill Repeats, Part 1", Device: "Garmin Edge 530", Uploader: "GARMIN_CONNECT", Distance: 40000, MovingTime: 5400, Rank: 1, Score: 20, Breakdown: map[string]float64{"Power Stream": 10, "GPS/Map Stream": 10}, Plan: PlanKeep},
+				{ID: "i2", Name: "Morning Ride", Device: "Phone", Uploader: "Strava", Distance: 39800.5, MovingTime: 5380, Rank: 2, Score: 7.5, Breakdown: map[string]float64{"GPS/Map Stream": 10, "Uploader Penalty: strava": -2.5}, Plan: PlanDelete},
+			},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSVReport(&buf, report); err != nil {
+		t.Fatalf("WriteCSVReport error: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+
+	want := [][]string{
+		{"athlete_id", "group_id", "group_start", "activity_id", "name", "rank", "total_score", "GPS/Map Stream", "Power Stream", "Uploader Penalty: strava", "device", "uploader", "distance_m", "moving_time_s", "plan"},
+		{"i0", "i1,i2", "0001-01-01T00:00:00", "i1", "Hill Repeats, Part 1", "1", "20", "10", "10", "0", "Garmin Edge 530", "GARMIN_CONNECT", "40000", "5400", "keep"},
+		{"i0", "i1,i2", "0001-01-01T00:00:00", "i2", "Morning Ride", "2", "7.5", "10", "0", "-2.5", "Phone", "Strava", "39800.5", "5380", "delete"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v\nwant %v", rows, want)
+	}
+}
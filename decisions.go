@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const defaultDecisionsFile = "decisions.jsonl"
+
+// DecisionMember captures one group member's scorecard at decision time
+type DecisionMember struct {
+	ID        string             `json:"id"`
+	Total     float64            `json:"total"`
+	Breakdown map[string]float64 `json:"breakdown"`
+	Features  map[string]float64 `json:"features"`
+	Fixed     float64            `json:"fixed"` // Score not explained by weights (device priority, penalties)
+}
+
+// DecisionRecord is a single interactive answer about a duplicate group
+type DecisionRecord struct {
+	Time     time.Time        `json:"time"`
+	Kind     string           `json:"kind"` // "delete", "name", "metadata" or "winner" (override of the scored winner)
+	Accepted bool             `json:"accepted"`
+	WinnerID string           `json:"winner_id"`
+	LoserID  string           `json:"loser_id,omitempty"`
+	Members  []DecisionMember `json:"members"`
+}
+
+// Member looks up a group member by activity ID
+func (r *DecisionRecord) Member(id string) *DecisionMember {
+	for i := range r.Members {
+		if r.Members[i].ID == id {
+			return &r.Members[i]
+		}
+	}
+	return nil
+}
+
+// DecisionsFile returns the configured decisions file path, or the default
+func (c *Config) DecisionsFile() string {
+	if c.DecisionsPath != "" {
+		return c.DecisionsPath
+	}
+	return defaultDecisionsFile
+}
+
+// newDecisionRecord snapshots the group's scorecards for a decision
+func newDecisionRecord(weights Weights, kind string, accepted bool, winnerID, loserID string, group []evaluatedActivity) DecisionRecord {
+	record := DecisionRecord{
+		Time:     time.Now(),
+		Kind:     kind,
+		Accepted: accepted,
+		WinnerID: winnerID,
+		LoserID:  loserID,
+	}
+	for _, e := range group {
+		record.Members = append(record.Members, DecisionMember{
+			ID:        e.Detail.ID,
+			Total:     e.Score.Total,
+			Breakdown: e.Score.Breakdown,
+			Features:  e.Score.Features,
+			Fixed:     e.Score.Total - weights.Apply(e.Score.Features),
+		})
+	}
+	return record
+}
+
+// AppendDecision appends a record to the JSONL decisions file
+func AppendDecision(path string, record DecisionRecord) error {
+	return appendJSONLine(path, record)
+}
+
+// LoadDecisions reads all records from the JSONL decisions file. A missing file
+// yields no records.
+func LoadDecisions(path string) ([]DecisionRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []DecisionRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var r DecisionRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return records, scanner.Err()
+}
+
+// recordDecision appends to the decisions file, warning rather than failing the run
+func recordDecision(w io.Writer, config *Config, record DecisionRecord) {
+	if err := AppendDecision(config.DecisionsFile(), record); err != nil {
+		fmt.Fprintf(w, "    ⚠️ Failed to record decision: %v\n", err)
+	}
+}
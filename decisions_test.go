@@ -0,0 +1,49 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDecisionsRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "decisions.jsonl")
+
+	// A missing file has no decisions yet
+	if records, err := LoadDecisions(path); err != nil || records != nil {
+		t.Fatalf("LoadDecisions on a missing file = %v, %v", records, err)
+	}
+
+	group := []evaluatedActivity{
+		{Detail: ActivityDetail{Activity: Activity{ID: "i1"}}, Score: Scorecard{Total: 25, Features: map[string]float64{"gps": 1, "power": 1}}},
+		{Detail: ActivityDetail{Activity: Activity{ID: "i2"}}, Score: Scorecard{Total: 14, Features: map[string]float64{"gps": 1}}},
+	}
+	weights := Weights{GPS: 10, Power: 10}
+	if err := AppendDecision(path, newDecisionRecord(weights, "delete", true, "i1", "i2", group)); err != nil {
+		t.Fatalf("AppendDecision error: %v", err)
+	}
+	if err := AppendDecision(path, newDecisionRecord(weights, "winner", false, "i2", "", group)); err != nil {
+		t.Fatalf("AppendDecision error: %v", err)
+	}
+
+	records, err := LoadDecisions(path)
+	if err != nil {
+		t.Fatalf("LoadDecisions error: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records; want 2", len(records))
+	}
+	first := records[0]
+	if first.Kind != "delete" || !first.Accepted || first.WinnerID != "i1" || first.LoserID != "i2" || len(first.Members) != 2 {
+		t.Errorf("first record = %+v", first)
+	}
+	// Fixed is the part of the score the weights don't explain
+	if m := first.Member("i1"); m == nil || m.Total != 25 || m.Fixed != 5 || m.Features["power"] != 1 {
+		t.Errorf("member i1 = %+v", m)
+	}
+	if m := first.Member("i2"); m == nil || m.Fixed != 4 {
+		t.Errorf("member i2 = %+v", m)
+	}
+	if records[1].Kind != "winner" || records[1].Accepted || records[1].Member("i3") != nil {
+		t.Errorf("second record = %+v", records[1])
+	}
+}
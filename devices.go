@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Fields reported by the devices command, in display order
+const (
+	FieldDevice      = "device_name"
+	FieldSource      = "source"
+	FieldOAuthClient = "oauth_client_name"
+	FieldPowerMeter  = "power_meter"
+)
+
+var deviceFields = []string{FieldDevice, FieldSource, FieldOAuthClient, FieldPowerMeter}
+
+// defaultUploaderPenalty is suggested for sync tools that produce duplicates
+const defaultUploaderPenalty = 4
+
+// DeviceStats counts the activities carrying one device/uploader string
+type DeviceStats struct {
+	Field      string
+	Value      string
+	Activities int
+	Types      map[string]int // Activity type -> count
+	InGroups   int            // Activities that were part of a duplicate group
+	Lost       int            // Grouped activities that did not win their group
+}
+
+// TypeSummary lists the activity types, most frequent first, e.g. "Ride×12, Run×3"
+func (d *DeviceStats) TypeSummary() string {
+	types := make([]string, 0, len(d.Types))
+	for t := range d.Types {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if d.Types[types[i]] != d.Types[types[j]] {
+			return d.Types[types[i]] > d.Types[types[j]]
+		}
+		return types[i] < types[j]
+	})
+	var parts []string
+	for _, t := range types {
+		parts = append(parts, fmt.Sprintf("%s×%d", valueOr(t, "?"), d.Types[t]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func deviceFieldValue(a *Activity, field string) string {
+	switch field {
+	case FieldDevice:
+		return a.DeviceName
+	case FieldSource:
+		return a.Source
+	case FieldOAuthClient:
+		return a.OAuthClientName
+	case FieldPowerMeter:
+		return a.PowerMeter
+	}
+	return ""
+}
+
+// summaryDetail approximates an activity's streams from the list summary, so
+// groups can be ranked without fetching every activity's details
+func summaryDetail(a Activity) ActivityDetail {
+	detail := ActivityDetail{Activity: a}
+	if a.HasGPS {
+		detail.StreamTypes = append(detail.StreamTypes, "latlng")
+	}
+	if a.AverageHeartrate > 0 {
+		detail.StreamTypes = append(detail.StreamTypes, "heartrate")
+	}
+	if a.AverageWatts > 0 {
+		detail.StreamTypes = append(detail.StreamTypes, "watts")
+	}
+	return detail
+}
+
+// BuildDeviceReport counts every distinct device name, source, OAuth client
+// and power meter, ordered by field and then by number of activities. Each
+// duplicate group is ranked from the list summaries to count its losers.
+func BuildDeviceReport(activities []Activity, scoring *ScoringEngine) []DeviceStats {
+	sorted := make([]Activity, len(activities))
+	copy(sorted, activities)
+	grouped := make(map[string]bool)
+	lost := make(map[string]bool)
+	for _, group := range groupActivities(sorted) {
+		details := make([]ActivityDetail, len(group))
+		for i, a := range group {
+			grouped[a.ID] = true
+			details[i] = summaryDetail(a)
+		}
+		for _, loser := range rankDetails(scoring, details)[1:] {
+			lost[loser.Detail.ID] = true
+		}
+	}
+
+	stats := make(map[[2]string]*DeviceStats)
+	for i := range activities {
+		a := &activities[i]
+		for _, field := range deviceFields {
+			value := deviceFieldValue(a, field)
+			if value == "" {
+				continue
+			}
+			key := [2]string{field, value}
+			st, ok := stats[key]
+			if !ok {
+				st = &DeviceStats{Field: field, Value: value, Types: make(map[string]int)}
+				stats[key] = st
+			}
+			st.Activities++
+			st.Types[a.Type]++
+			if grouped[a.ID] {
+				st.InGroups++
+			}
+			if lost[a.ID] {
+				st.Lost++
+			}
+		}
+	}
+
+	order := make(map[string]int)
+	for i, f := range deviceFields {
+		order[f] = i
+	}
+	report := make([]DeviceStats, 0, len(stats))
+	for _, st := range stats {
+		report = append(report, *st)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		a, b := report[i], report[j]
+		if a.Field != b.Field {
+			return order[a.Field] < order[b.Field]
+		}
+		if a.Activities != b.Activities {
+			return a.Activities > b.Activities
+		}
+		return a.Value < b.Value
+	})
+	return report
+}
+
+// isBarometricDevice reports whether the device name matches a built-in
+// barometric altimeter hint
+func isBarometricDevice(name string) bool {
+	name = strings.ToLower(name)
+	for _, hint := range defaultBarometricDevices {
+		if strings.Contains(name, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// SuggestDevicePriority orders the recording devices for device_priority:
+// dedicated head units and watches (barometric altimeter) first, then by use
+func SuggestDevicePriority(report []DeviceStats) []string {
+	var devices []DeviceStats
+	for _, st := range report {
+		if st.Field == FieldDevice {
+			devices = append(devices, st)
+		}
+	}
+	sort.SliceStable(devices, func(i, j int) bool {
+		return isBarometricDevice(devices[i].Value) && !isBarometricDevice(devices[j].Value)
+	})
+	var names []string
+	for _, d := range devices {
+		names = append(names, d.Value)
+	}
+	return names
+}
+
+// SuggestUploaderPenalties penalizes the third-party sync tools (OAuth clients
+// and Strava imports) whose activities lose most of the duplicate groups they
+// appear in
+func SuggestUploaderPenalties(report []DeviceStats) map[string]float64 {
+	penalties := make(map[string]float64)
+	for _, st := range report {
+		if st.Lost*2 <= st.InGroups {
+			continue
+		}
+		if st.Field == FieldOAuthClient || (st.Field == FieldSource && strings.EqualFold(st.Value, "STRAVA")) {
+			penalties[st.Value] = defaultUploaderPenalty
+		}
+	}
+	return penalties
+}
+
+// WriteStarterConfig writes a device_priority/uploader_penalties block for config.yml
+func WriteStarterConfig(w io.Writer, devicePriority []string, penalties map[string]float64) {
+	fmt.Fprintln(w, "# Device Hierarchy (earlier items get a higher bonus)")
+	fmt.Fprintln(w, "device_priority:")
+	if len(devicePriority) == 0 {
+		fmt.Fprintln(w, "  []")
+	}
+	for _, d := range devicePriority {
+		fmt.Fprintf(w, "  - %q\n", d)
+	}
+
+	fmt.Fprintln(w, "\n# Penalize activities from sync tools that produce duplicates")
+	fmt.Fprintln(w, "uploader_penalties:")
+	if len(penalties) == 0 {
+		fmt.Fprintln(w, "  {}")
+	}
+	keys := make([]string, 0, len(penalties))
+	for k := range penalties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "  %q: %g\n", k, penalties[k])
+	}
+}
+
+// runDevicesReport lists the device and uploader strings seen in the window,
+// optionally followed by a starter config block
+func runDevicesReport(client *IntervalsClient, scoring *ScoringEngine, oldest, newest time.Time, starter bool) {
+	fmt.Printf("📟 Scanning devices and uploaders from %s to %s...\n", oldest.Format("2006-01-02"), newest.Format("2006-01-02"))
+
+	activities, err := client.ListActivities(oldest, newest)
+	if err != nil {
+		log.Fatalf("Error fetching activities: %v", err)
+	}
+	report := BuildDeviceReport(activities, scoring)
+	if len(report) == 0 {
+		fmt.Println("No devices or uploaders found.")
+		return
+	}
+
+	field := ""
+	for _, st := range report {
+		if st.Field != field {
+			field = st.Field
+			fmt.Printf("\n%-40s %6s %9s %5s  %s\n", strings.ToUpper(field), "ACTS", "IN GROUPS", "LOST", "TYPES")
+		}
+		fmt.Printf("%-40s %6d %9d %5d  %s\n", st.Value, st.Activities, st.InGroups, st.Lost, st.TypeSummary())
+	}
+
+	if starter {
+		fmt.Println("\n# Starter config; review the order and penalties before pasting into config.yml")
+		WriteStarterConfig(os.Stdout, SuggestDevicePriority(report), SuggestUploaderPenalties(report))
+	}
+}
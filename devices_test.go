@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func devicesTestActivities() []Activity {
+	at := func(d, h int) IntervalsTime {
+		return IntervalsTime{time.Date(2024, 5, d, h, 0, 0, 0, time.UTC)}
+	}
+	return []Activity{
+		{ID: "i1", Type: "Ride", DeviceName: "Garmin Edge 530", Source: "GARMIN_CONNECT", PowerMeter: "Assioma", HasGPS: true, AverageWatts: 210, StartDateLocal: at(1, 8)},
+		{ID: "i2", Type: "Ride", DeviceName: "Phone", Source: "OAUTH_CLIENT", OAuthClientName: "RunGap", HasGPS: true, StartDateLocal: at(1, 8)},
+		{ID: "i3", Type: "Run", DeviceName: "Phone", Source: "STRAVA", StartDateLocal: at(2, 8)},
+		{ID: "i4", Type: "Ride", DeviceName: "Phone", Source: "STRAVA", HasGPS: true, AverageWatts: 180, StartDateLocal: at(3, 8)},
+		{ID: "i5", Type: "Ride", DeviceName: "Zwift", Source: "ZWIFT", StartDateLocal: at(3, 8)},
+	}
+}
+
+func devicesTestScoring() *ScoringEngine {
+	return NewScoringEngine(&Config{Weights: defaultWeights})
+}
+
+func TestBuildDeviceReport(t *testing.T) {
+	activities := devicesTestActivities()
+	report := BuildDeviceReport(activities, devicesTestScoring())
+
+	if activities[3].ID != "i4" {
+		t.Error("BuildDeviceReport reordered its input")
+	}
+
+	want := []struct {
+		field, value             string
+		activities, groups, lost int
+		types                    string
+	}{
+		{FieldDevice, "Phone", 3, 2, 1, "Ride×2, Run×1"},
+		{FieldDevice, "Garmin Edge 530", 1, 1, 0, "Ride×1"},
+		{FieldDevice, "Zwift", 1, 1, 1, "Ride×1"},
+		{FieldSource, "STRAVA", 2, 1, 0, "Ride×1, Run×1"},
+		{FieldSource, "GARMIN_CONNECT", 1, 1, 0, "Ride×1"},
+		{FieldSource, "OAUTH_CLIENT", 1, 1, 1, "Ride×1"},
+		{FieldSource, "ZWIFT", 1, 1, 1, "Ride×1"},
+		{FieldOAuthClient, "RunGap", 1, 1, 1, "Ride×1"},
+		{FieldPowerMeter, "Assioma", 1, 1, 0, "Ride×1"},
+	}
+	if len(report) != len(want) {
+		t.Fatalf("got %d rows; want %d: %+v", len(report), len(want), report)
+	}
+	for i, w := range want {
+		r := report[i]
+		if r.Field != w.field || r.Value != w.value || r.Activities != w.activities || r.InGroups != w.groups || r.Lost != w.lost || r.TypeSummary() != w.types {
+			t.Errorf("row %d = %s %s %d %d %d %q; want %+v", i, r.Field, r.Value, r.Activities, r.InGroups, r.Lost, r.TypeSummary(), w)
+		}
+	}
+}
+
+func TestStarterConfig(t *testing.T) {
+	report := BuildDeviceReport(devicesTestActivities(), devicesTestScoring())
+
+	priority := SuggestDevicePriority(report)
+	if len(priority) != 3 || priority[0] != "Garmin Edge 530" || priority[1] != "Phone" {
+		t.Errorf("device priority = %v; want barometric head unit first", priority)
+	}
+
+	penalties := SuggestUploaderPenalties(report)
+	if len(penalties) != 1 || penalties["RunGap"] != defaultUploaderPenalty {
+		t.Errorf("penalties = %v; want only RunGap (STRAVA won its group)", penalties)
+	}
+
+	var b strings.Builder
+	WriteStarterConfig(&b, priority, penalties)
+	var config Config
+	if err := yaml.Unmarshal([]byte(b.String()), &config); err != nil {
+		t.Fatalf("starter block is not valid YAML: %v\n%s", err, b.String())
+	}
+	if len(config.DevicePriority) != 3 || config.UploaderPenalties["RunGap"] != defaultUploaderPenalty {
+		t.Errorf("parsed starter block = %+v", config)
+	}
+}
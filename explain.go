@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// WeightChange describes a single weight adjustment that would flip a result
+type WeightChange struct {
+	Name    string
+	Current float64
+	Target  float64 // The weight must go strictly past this value
+	Raise   bool    // True when the weight must rise above Target, false when it must fall below
+}
+
+// FlipChanges lists the single-weight changes that would make the challenger
+// outscore the winner. Changes that would need a negative weight are omitted.
+func FlipChanges(winner, challenger Scorecard, weights Weights) []WeightChange {
+	margin := winner.Total - challenger.Total
+
+	var changes []WeightChange
+	for _, f := range weights.weightFields() {
+		d := winner.Features[f.Name] - challenger.Features[f.Name]
+		if d == 0 {
+			continue
+		}
+		target := *f.Value - margin/d
+		if target < 0 {
+			continue
+		}
+		changes = append(changes, WeightChange{
+			Name:    f.Name,
+			Current: *f.Value,
+			Target:  target,
+			Raise:   d < 0,
+		})
+	}
+	return changes
+}
+
+// decidingFactor explains why a ranked above b
+func decidingFactor(scoring *ScoringEngine, a, b *evaluatedActivity) string {
+	factor := scoring.DecidingFactor(a, b)
+	if factor == "score" {
+		return fmt.Sprintf("score (%.2f vs %.2f)", a.Score.Total, b.Score.Total)
+	}
+	return "tie-breaker: " + factor
+}
+
+func formatTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// findGroup locates the duplicate group containing the activity
+func findGroup(client *IntervalsClient, detail *ActivityDetail) ([]Activity, error) {
+	start := detail.StartDateLocal.Time
+	activities, err := client.ListActivities(start.AddDate(0, 0, -1), start.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groupActivities(activities) {
+		for _, a := range group {
+			if a.ID == detail.ID {
+				return group, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// runExplain prints the full scoring of an activity's duplicate group side by side
+func runExplain(client *IntervalsClient, scoring *ScoringEngine, id string) {
+	detail, err := client.GetActivityDetail(id)
+	if err != nil {
+		log.Fatalf("Error fetching activity %s: %v", id, err)
+	}
+
+	group, err := findGroup(client, detail)
+	if err != nil {
+		log.Fatalf("Error fetching activities: %v", err)
+	}
+	if group == nil {
+		fmt.Printf("Activity %s (%s) is not part of a duplicate group.\n", id, detail.Name)
+		return
+	}
+
+	details := fetchDetails(os.Stdout, client, group)
+	if len(details) <= 1 {
+		fmt.Printf("Could not load enough group members to compare.\n")
+		return
+	}
+	ranked := rankDetails(scoring, details)
+
+	fmt.Printf("🔎 Duplicate group starting around %s (%d members)\n\n",
+		ranked[0].Detail.StartDateLocal.Time.Format("2006-01-02 15:04:05"), len(ranked))
+	printScorecardTable(ranked)
+
+	winner := ranked[0]
+	fmt.Printf("\n🏆 Winner: %s, decided by %s over %s\n", winner.Detail.ID, decidingFactor(scoring, &winner, &ranked[1]), ranked[1].Detail.ID)
+
+	// Explain what would flip the result for the requested activity, or for the
+	// runner-up when the requested activity is the winner
+	challenger := ranked[1]
+	for _, e := range ranked[1:] {
+		if e.Detail.ID == id {
+			challenger = e
+		}
+	}
+
+	changes := FlipChanges(winner.Score, challenger.Score, scoring.Config.Weights)
+	if len(changes) == 0 {
+		fmt.Printf("\nNo single weight change would make %s win; the difference comes from device priority or uploader penalties.\n", challenger.Detail.ID)
+		return
+	}
+	fmt.Printf("\nTo make %s win instead (changing one weight):\n", challenger.Detail.ID)
+	for _, c := range changes {
+		direction := "below"
+		if c.Raise {
+			direction = "above"
+		}
+		fmt.Printf("  - %s: %g → %s %.2f\n", c.Name, c.Current, direction, c.Target)
+	}
+}
+
+// printScorecardTable prints every member's breakdown as columns
+func printScorecardTable(ranked []evaluatedActivity) {
+	keys := make(map[string]bool)
+	for _, e := range ranked {
+		for k := range e.Score.Breakdown {
+			keys[k] = true
+		}
+	}
+	var rows []string
+	for k := range keys {
+		rows = append(rows, k)
+	}
+	sort.Strings(rows)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(w, "\t")
+	for i, e := range ranked {
+		fmt.Fprintf(w, "#%d %s\t", i+1, e.Detail.ID)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprint(w, "Device\t")
+	for _, e := range ranked {
+		fmt.Fprintf(w, "%s\t", describeSystem(&e.Detail))
+	}
+	fmt.Fprintln(w)
+	for _, k := range rows {
+		fmt.Fprintf(w, "%s\t", k)
+		for _, e := range ranked {
+			if v, ok := e.Score.Breakdown[k]; ok {
+				fmt.Fprintf(w, "%.2f\t", v)
+			} else {
+				fmt.Fprint(w, "-\t")
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprint(w, "TOTAL\t")
+	for _, e := range ranked {
+		fmt.Fprintf(w, "%.2f\t", e.Score.Total)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprint(w, "Updated\t")
+	for _, e := range ranked {
+		fmt.Fprintf(w, "%s\t", formatTimestamp(e.Detail.Updated.Time))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprint(w, "Created\t")
+	for _, e := range ranked {
+		fmt.Fprintf(w, "%s\t", formatTimestamp(e.Detail.CreatedAt.Time))
+	}
+	fmt.Fprintln(w)
+	w.Flush()
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFlipChanges(t *testing.T) {
+	weights := Weights{GPS: 12, HeartRate: 5, Power: 10}
+
+	// Both have power; the winner adds GPS (22) and the challenger heart rate (15)
+	winner := Scorecard{Total: 22, Features: map[string]float64{"gps": 1, "power": 1}}
+	challenger := Scorecard{Total: 15, Features: map[string]float64{"heartrate": 1, "power": 1}}
+
+	changes := FlipChanges(winner, challenger, weights)
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %+v", changes)
+	}
+
+	byName := make(map[string]WeightChange)
+	for _, c := range changes {
+		byName[c.Name] = c
+	}
+	if c := byName["gps"]; c.Raise || math.Abs(c.Target-5) > 1e-9 {
+		t.Errorf("gps change = %+v; want below 5", c)
+	}
+	if c := byName["heartrate"]; !c.Raise || math.Abs(c.Target-12) > 1e-9 {
+		t.Errorf("heartrate change = %+v; want above 12", c)
+	}
+}
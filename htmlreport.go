@@ -0,0 +1,233 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// htmlBar is one score component drawn as a bar scaled to its group
+type htmlBar struct {
+	Name    string
+	Value   float64
+	Width   float64 // Percent of the largest component in the group
+	Penalty bool
+}
+
+// htmlMember is a member card in the HTML report
+type htmlMember struct {
+	*MemberReport
+	Bars   []htmlBar
+	Winner bool
+}
+
+type htmlGroup struct {
+	*GroupReport
+	Cards []htmlMember
+}
+
+// breakdownBars orders a member's score components by name and scales them
+// against the largest absolute component in the group
+func breakdownBars(breakdown map[string]float64, scale float64) []htmlBar {
+	var names []string
+	for name := range breakdown {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var bars []htmlBar
+	for _, name := range names {
+		v := breakdown[name]
+		width := 0.0
+		if scale > 0 {
+			width = math.Abs(v) / scale * 100
+		}
+		bars = append(bars, htmlBar{Name: name, Value: v, Width: width, Penalty: v < 0})
+	}
+	return bars
+}
+
+func newHTMLGroup(g *GroupReport) htmlGroup {
+	scale := 0.0
+	for _, m := range g.Members {
+		for _, v := range m.Breakdown {
+			scale = math.Max(scale, math.Abs(v))
+		}
+	}
+
+	group := htmlGroup{GroupReport: g}
+	for _, m := range g.Members {
+		group.Cards = append(group.Cards, htmlMember{
+			MemberReport: m,
+			Bars:         breakdownBars(m.Breakdown, scale),
+			Winner:       m.ID == g.WinnerID,
+		})
+	}
+	return group
+}
+
+// reportStyle is the inline stylesheet shared by the HTML report and the review UI
+const reportStyle = `body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; background: #f6f7f9; }
+h1 { margin-bottom: 0.2em; }
+h2.athlete { margin: 1.5em 0 0.2em; padding-top: 0.5em; border-top: 2px solid #ccc; }
+.meta { color: #666; margin-bottom: 1.5em; }
+.summary td { padding: 0.1em 1em 0.1em 0; }
+.group { background: #fff; border: 1px solid #ddd; border-radius: 6px; padding: 1em; margin: 1.5em 0; }
+.group h2 { font-size: 1.1em; margin: 0 0 0.5em; }
+.decision { color: #444; margin-bottom: 0.8em; }
+.cards { display: flex; flex-wrap: wrap; gap: 1em; }
+.card { flex: 1 1 280px; border: 1px solid #ccc; border-radius: 6px; padding: 0.8em; }
+.card.winner { border: 2px solid #2e9e44; background: #f3fbf4; }
+.card h3 { font-size: 1em; margin: 0 0 0.4em; }
+.card dl { display: grid; grid-template-columns: auto 1fr; gap: 0.1em 0.8em; margin: 0.4em 0; font-size: 0.9em; }
+.card dt { color: #666; }
+.card dd { margin: 0; }
+.plan { display: inline-block; padding: 0.1em 0.5em; border-radius: 3px; font-size: 0.8em; font-weight: bold; text-transform: uppercase; background: #ddd; }
+.plan-keep { background: #2e9e44; color: #fff; }
+.plan-delete { background: #c0392b; color: #fff; }
+.plan-protected { background: #2c6fbb; color: #fff; }
+.bars { font-size: 0.8em; }
+.bar { display: grid; grid-template-columns: 7em 1fr 3em; align-items: center; gap: 0.5em; margin: 2px 0; }
+.bar .track { background: #eee; height: 0.8em; border-radius: 2px; }
+.bar .fill { background: #4a90d9; height: 100%; border-radius: 2px; }
+.bar .fill.penalty { background: #d9534f; }
+.bar .value { text-align: right; }
+.warning { color: #b35900; font-weight: bold; font-size: 0.9em; }
+.ignored { color: #888; }
+ul.actions { font-size: 0.9em; }
+.status-error { color: #c0392b; }
+`
+
+var htmlFuncs = template.FuncMap{
+	"style":    func() template.CSS { return template.CSS(reportStyle) },
+	"distance": formatDistance,
+	"duration": formatDuration,
+	"percent":  func(f float64) string { return fmt.Sprintf("%.1f%%", f*100) },
+	"score":    func(f float64) string { return fmt.Sprintf("%.1f", f) },
+	"date":     func(t time.Time) string { return t.Format("2006-01-02") },
+	"datetime": func(t time.Time) string { return t.Format("2006-01-02 15:04") },
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(htmlFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Duplicate review{{with index .Athletes 0}} {{date .Oldest}} – {{date .Newest}}{{end}}</title>
+<style>
+{{style}}
+</style>
+</head>
+<body>
+<h1>Duplicate review</h1>
+{{range .Athletes}}
+{{if $.Multi}}<h2 class="athlete">{{.Label}}</h2>{{end}}
+<div class="meta">Athlete {{.AthleteID}} · {{date .Oldest}} to {{date .Newest}}{{if .DryRun}} · dry run{{end}}</div>
+{{with .Summary}}
+<table class="summary">
+<tr><td>Activities scanned</td><td>{{.Scanned}}</td></tr>
+<tr><td>Duplicate groups</td><td>{{.Groups}}</td></tr>
+<tr><td>Split recordings</td><td>{{.Splits}}</td></tr>
+<tr><td>Planned changes</td><td>{{.Planned}}</td></tr>
+<tr><td>Deleted / updated / uploaded</td><td>{{.Deleted}} / {{.Updated}} / {{.Uploaded}}</td></tr>
+<tr><td>Kept due to mismatch</td><td>{{.MismatchSkipped}}</td></tr>
+<tr><td>Protected</td><td>{{.Protected}}</td></tr>
+{{if .Errors}}<tr><td>Errors</td><td class="status-error">{{.Errors}}</td></tr>{{end}}
+</table>
+{{end}}
+{{range .HTMLGroups}}
+<div class="group">
+<h2>{{datetime .Start}} · {{len .Members}} recordings</h2>
+{{if .Ignored}}<div class="decision ignored">Ignored (on the ignore list)</div>{{else}}
+<div class="decision">Winner <strong>{{.WinnerID}}</strong>{{if eq .DecidedBy "manual"}}, chosen manually{{else if and .DecidedBy (ne .DecidedBy "score")}}, decided by tie-breaker <em>{{.DecidedBy}}</em>{{end}}</div>
+{{end}}
+<div class="cards">
+{{range .Cards}}
+<div class="card{{if .Winner}} winner{{end}}">
+<h3>#{{.Rank}} {{.Name}} <span class="plan plan-{{.Plan}}">{{.Plan}}</span></h3>
+<dl>
+<dt>ID</dt><dd>{{.ID}}</dd>
+<dt>Type</dt><dd>{{.Type}}</dd>
+<dt>Device</dt><dd>{{or .Device "—"}}</dd>
+<dt>Uploader</dt><dd>{{or .Uploader "—"}}</dd>
+<dt>Distance</dt><dd>{{distance .Distance}}{{if not .Winner}} (Δ {{percent .DistDiff}}){{end}}</dd>
+<dt>Moving time</dt><dd>{{duration .MovingTime}}{{if not .Winner}} (Δ {{percent .TimeDiff}}){{end}}</dd>
+<dt>Score</dt><dd><strong>{{score .Score}}</strong></dd>
+</dl>
+{{if .DistMismatch}}<div class="warning">⚠ Distance mismatch</div>{{end}}
+{{if .TimeMismatch}}<div class="warning">⚠ Moving time mismatch</div>{{end}}
+{{if .Protected}}<div class="warning">🛡 Protected: {{.Protected}}</div>{{end}}
+<div class="bars">
+{{range .Bars}}<div class="bar"><span>{{.Name}}</span><div class="track"><div class="fill{{if .Penalty}} penalty{{end}}" style="width: {{printf "%.0f" .Width}}%"></div></div><span class="value">{{score .Value}}</span></div>
+{{end}}
+</div>
+</div>
+{{end}}
+</div>
+{{if .Actions}}
+<ul class="actions">
+{{range .Actions}}<li class="status-{{.Status}}">{{.Action}} {{.ActivityID}}: {{.Status}}{{if .Error}} ({{.Error}}){{end}}</li>
+{{end}}
+</ul>
+{{end}}
+</div>
+{{end}}
+{{range .Splits}}
+<div class="group">
+<h2>{{datetime .Start}} · split recording ({{len .Fragments}} fragments)</h2>
+<div class="decision">Fragments: {{range $i, $id := .Fragments}}{{if $i}}, {{end}}{{$id}}{{end}}{{if .CompleteID}} · complete recording <strong>{{.CompleteID}}</strong>{{end}}</div>
+{{if .Actions}}
+<ul class="actions">
+{{range .Actions}}<li class="status-{{.Status}}">{{.Action}} {{.ActivityID}}: {{.Status}}{{if .Error}} ({{.Error}}){{end}}</li>
+{{end}}
+</ul>
+{{end}}
+</div>
+{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+// htmlAthlete is one athlete's section of the HTML report
+type htmlAthlete struct {
+	*RunReport
+	Summary    RunSummary
+	HTMLGroups []htmlGroup
+}
+
+// WriteHTMLReport renders the runs as a self-contained HTML page (inline CSS, no
+// external assets) for reviewing the planned changes, with a section per athlete
+func WriteHTMLReport(w io.Writer, reports ...*RunReport) error {
+	if len(reports) == 0 {
+		return fmt.Errorf("no runs to report")
+	}
+	var athletes []htmlAthlete
+	for _, r := range reports {
+		var groups []htmlGroup
+		for _, g := range r.Groups {
+			groups = append(groups, newHTMLGroup(g))
+		}
+		athletes = append(athletes, htmlAthlete{r, r.Summary(), groups})
+	}
+	return htmlReportTemplate.Execute(w, struct {
+		Athletes []htmlAthlete
+		Multi    bool
+	}{athletes, len(athletes) > 1})
+}
+
+// saveHTMLReport writes the HTML report to path
+func saveHTMLReport(path string, reports []*RunReport) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteHTMLReport(f, reports...); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBreakdownBars(t *testing.T) {
+	bars := breakdownBars(map[string]float64{"power": 10, "gps": 5, "low_battery": -2.5}, 10)
+
+	want := []htmlBar{
+		{Name: "gps", Value: 5, Width: 50},
+		{Name: "low_battery", Value: -2.5, Width: 25, Penalty: true},
+		{Name: "power", Value: 10, Width: 100},
+	}
+	if len(bars) != len(want) {
+		t.Fatalf("got %d bars; want %d", len(bars), len(want))
+	}
+	for i := range want {
+		if bars[i] != want[i] {
+			t.Errorf("bar %d = %+v; want %+v", i, bars[i], want[i])
+		}
+	}
+}
+
+func TestWriteHTMLReport(t *testing.T) {
+	report := &RunReport{
+		AthleteID: "i0",
+		DryRun:    true,
+		Scanned:   2,
+		Groups: []*GroupReport{{
+			ID:        "i1,i2",
+			WinnerID:  "i1",
+			DecidedBy: "score",
+			Members: []*MemberReport{
+				{ID: "i1", Name: "Hill <Repeats>", Device: "Garmin Edge 530", Rank: 1, Score: 20, Breakdown: map[string]float64{"gps": 10, "power": 10}, Plan: PlanKeep},
+				{ID: "i2", Name: "Morning Ride", Device: "Phone", Rank: 2, Score: 10, Breakdown: map[string]float64{"gps": 10}, DistDiff: 0.4, DistMismatch: true, Plan: PlanMismatch},
+			},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteHTMLReport(&buf, report); err != nil {
+		t.Fatalf("WriteHTMLReport error: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{"Hill &lt;Repeats&gt;", "Garmin Edge 530", "Distance mismatch", "plan-mismatch", "40.0%", "dry run"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+	for _, external := range []string{"<link", "<script src", "url("} {
+		if strings.Contains(out, external) {
+			t.Errorf("report references external assets via %q", external)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultIgnoreFile = "ignore.json"
+
+// IgnoredGroup is a duplicate group the user reviewed and declined
+type IgnoredGroup struct {
+	IDs    []string  `json:"ids"` // Sorted member IDs
+	Added  time.Time `json:"added"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// IgnoreList is the persisted set of ignored groups
+type IgnoreList struct {
+	Groups []IgnoredGroup `json:"groups"`
+
+	path string
+}
+
+// groupKey identifies a group by its sorted member IDs
+func groupKey(ids []string) string {
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// activityIDs returns the IDs of a group's members
+func activityIDs(group []Activity) []string {
+	var ids []string
+	for _, a := range group {
+		ids = append(ids, a.ID)
+	}
+	return ids
+}
+
+// IgnoreFile returns the configured ignore list path, or the default
+func (c *Config) IgnoreFile() string {
+	if c.IgnorePath != "" {
+		return c.IgnorePath
+	}
+	return defaultIgnoreFile
+}
+
+// LoadIgnoreList reads the ignore list. A missing file yields an empty list.
+func LoadIgnoreList(path string) (*IgnoreList, error) {
+	list := &IgnoreList{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return list, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, list); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return list, nil
+}
+
+// Save writes the ignore list back to disk
+func (l *IgnoreList) Save() error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(l.path, data, 0644)
+}
+
+// Contains reports whether a group with exactly these members is ignored
+func (l *IgnoreList) Contains(ids []string) bool {
+	key := groupKey(ids)
+	for _, g := range l.Groups {
+		if groupKey(g.IDs) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Add ignores a group, returning false if it was already ignored
+func (l *IgnoreList) Add(ids []string, reason string) bool {
+	if l.Contains(ids) {
+		return false
+	}
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+	l.Groups = append(l.Groups, IgnoredGroup{IDs: sorted, Added: time.Now(), Reason: reason})
+	return true
+}
+
+// Remove drops every ignored group containing any of the IDs and returns how many were removed
+func (l *IgnoreList) Remove(ids []string) int {
+	remove := make(map[string]bool)
+	for _, id := range ids {
+		remove[id] = true
+	}
+
+	var kept []IgnoredGroup
+	for _, g := range l.Groups {
+		match := false
+		for _, id := range g.IDs {
+			if remove[id] {
+				match = true
+				break
+			}
+		}
+		if !match {
+			kept = append(kept, g)
+		}
+	}
+	removed := len(l.Groups) - len(kept)
+	l.Groups = kept
+	return removed
+}
+
+// runIgnore manages the ignore list: list, add <id...>, remove <id...>.
+// Adding a single ID ignores the duplicate group it currently belongs to.
+func runIgnore(client *IntervalsClient, config *Config, args []string) {
+	list, err := LoadIgnoreList(config.IgnoreFile())
+	if err != nil {
+		log.Fatalf("Error loading ignore list: %v", err)
+	}
+
+	action := "list"
+	if len(args) > 0 {
+		action, args = args[0], args[1:]
+	}
+
+	switch action {
+	case "list":
+		if len(list.Groups) == 0 {
+			fmt.Println("No ignored groups.")
+			return
+		}
+		for _, g := range list.Groups {
+			reason := ""
+			if g.Reason != "" {
+				reason = " - " + g.Reason
+			}
+			fmt.Printf("  🙈 %s (added %s)%s\n", strings.Join(g.IDs, ", "), g.Added.Format("2006-01-02"), reason)
+		}
+		return
+
+	case "add":
+		if len(args) == 0 {
+			log.Fatalf("Usage: intervals-deduper ignore add <activity-id> [activity-id...]")
+		}
+		ids := args
+		if len(ids) == 1 {
+			detail, err := client.GetActivityDetail(ids[0])
+			if err != nil {
+				log.Fatalf("Error fetching activity %s: %v", ids[0], err)
+			}
+			group, err := findGroup(client, detail)
+			if err != nil {
+				log.Fatalf("Error fetching activities: %v", err)
+			}
+			if group == nil {
+				log.Fatalf("Activity %s is not part of a duplicate group", ids[0])
+			}
+			ids = activityIDs(group)
+		}
+		if !list.Add(ids, "added manually") {
+			fmt.Printf("Group %s is already ignored.\n", groupKey(ids))
+			return
+		}
+		fmt.Printf("🙈 Ignoring group %s\n", groupKey(ids))
+
+	case "remove":
+		if len(args) == 0 {
+			log.Fatalf("Usage: intervals-deduper ignore remove <activity-id> [activity-id...]")
+		}
+		n := list.Remove(args)
+		fmt.Printf("Removed %d ignored group(s).\n", n)
+		if n == 0 {
+			return
+		}
+
+	default:
+		log.Fatalf("Unknown ignore action: %s (expected list, add or remove)", action)
+	}
+
+	if err := list.Save(); err != nil {
+		log.Fatalf("Error saving ignore list: %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ignore.json")
+
+	list, err := LoadIgnoreList(path)
+	if err != nil {
+		t.Fatalf("LoadIgnoreList error: %v", err)
+	}
+	if !list.Add([]string{"i2", "i1"}, "declined") {
+		t.Fatal("expected group to be added")
+	}
+	if list.Add([]string{"i1", "i2"}, "declined") {
+		t.Error("expected duplicate add to be rejected")
+	}
+	if err := list.Save(); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+
+	list, err = LoadIgnoreList(path)
+	if err != nil {
+		t.Fatalf("LoadIgnoreList error: %v", err)
+	}
+	if !list.Contains([]string{"i1", "i2"}) {
+		t.Error("expected saved group to be ignored")
+	}
+	// A new member changes the group, so it is reviewed again
+	if list.Contains([]string{"i1", "i2", "i3"}) {
+		t.Error("expected changed membership not to be ignored")
+	}
+
+	if n := list.Remove([]string{"i2"}); n != 1 || len(list.Groups) != 0 {
+		t.Errorf("Remove = %d, remaining %d; want 1, 0", n, len(list.Groups))
+	}
+}
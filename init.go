@@ -0,0 +1,273 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultInitScanDays is how far back init looks for devices and uploaders
+const defaultInitScanDays = 90
+
+// initAnswers are the settings collected by the init wizard
+type initAnswers struct {
+	APIKey            string
+	AthleteID         string
+	Name              string
+	DaysToSync        int
+	DevicePriority    []string
+	UploaderPenalties map[string]float64
+}
+
+// prompt asks for a value on stdin, using def for an empty response
+func prompt(label, def string) string {
+	if def != "" {
+		fmt.Printf("%s [%s]: ", label, def)
+	} else {
+		fmt.Printf("%s: ", label)
+	}
+	response, _ := stdin.ReadString('\n')
+	if response = strings.TrimSpace(response); response != "" {
+		return response
+	}
+	return def
+}
+
+// parseSelection parses 1-based, comma-separated choices like "2, 1" into
+// indexes in the order given. "none" selects nothing.
+func parseSelection(input string, n int) ([]int, error) {
+	input = strings.TrimSpace(input)
+	if strings.EqualFold(input, "none") {
+		return []int{}, nil
+	}
+	seen := make(map[int]bool)
+	var selected []int
+	for _, field := range strings.FieldsFunc(input, func(r rune) bool { return r == ',' || r == ' ' }) {
+		i, err := strconv.Atoi(field)
+		if err != nil || i < 1 || i > n {
+			return nil, fmt.Errorf("%q is not a number between 1 and %d", field, n)
+		}
+		if !seen[i] {
+			seen[i] = true
+			selected = append(selected, i-1)
+		}
+	}
+	return selected, nil
+}
+
+// formatSelection renders indexes as the 1-based list accepted by parseSelection
+func formatSelection(indexes []int) string {
+	if len(indexes) == 0 {
+		return "none"
+	}
+	var parts []string
+	for _, i := range indexes {
+		parts = append(parts, strconv.Itoa(i+1))
+	}
+	return strings.Join(parts, ",")
+}
+
+// choose lists the options and asks for an ordered selection until the answer parses
+func choose(label string, options []string, suggested []int) []int {
+	for i, o := range options {
+		fmt.Printf("   %d. %s\n", i+1, o)
+	}
+	for {
+		selected, err := parseSelection(prompt(label, formatSelection(suggested)), len(options))
+		if err == nil {
+			return selected
+		}
+		fmt.Printf("   %v\n", err)
+	}
+}
+
+// indexesOf finds the positions of values in options
+func indexesOf(options, values []string) []int {
+	var indexes []int
+	for _, v := range values {
+		for i, o := range options {
+			if o == v {
+				indexes = append(indexes, i)
+				break
+			}
+		}
+	}
+	return indexes
+}
+
+// WriteWeights writes a commented weights block; config.example.yml carries
+// the same block for defaultWeights
+func WriteWeights(w io.Writer, weights Weights) {
+	fmt.Fprintln(w, "# Weights for Heuristic Scoring")
+	fmt.Fprintln(w, "# Higher numbers mean the metric is more important")
+	fmt.Fprintln(w, "weights:")
+	fields := weights.weightFields()
+	lines := make([]string, len(fields))
+	width := 0
+	for i, f := range fields {
+		lines[i] = fmt.Sprintf("  %s: %g", f.Name, *f.Value)
+		if _, ok := weightComments[f.Name]; ok {
+			width = max(width, len(lines[i]))
+		}
+	}
+	for i, f := range fields {
+		if comment, ok := weightComments[f.Name]; ok {
+			fmt.Fprintf(w, "%-*s # %s\n", width, lines[i], comment)
+		} else {
+			fmt.Fprintln(w, lines[i])
+		}
+	}
+}
+
+// WriteInitConfig writes a complete config.yml from the wizard's answers
+func WriteInitConfig(w io.Writer, a initAnswers) {
+	fmt.Fprintf(w, "# Intervals De-Duper (HE) Configuration\n")
+	fmt.Fprintf(w, "# Generated by `intervals-deduper init` on %s. See config.example.yml for every option.\n\n", time.Now().Format("2006-01-02"))
+
+	fmt.Fprintf(w, "# API Credentials (or use ENV vars: INTERVALS_API_KEY, INTERVALS_ATHLETE_ID)\n")
+	fmt.Fprintf(w, "api_key: %q\n", a.APIKey)
+	fmt.Fprintf(w, "athlete_id: %q\n", a.AthleteID)
+	if a.Name != "" {
+		fmt.Fprintf(w, "name: %q\n", a.Name)
+	}
+
+	fmt.Fprintln(w)
+	WriteWeights(w, defaultWeights)
+	fmt.Fprintln(w)
+	WriteStarterConfig(w, a.DevicePriority, a.UploaderPenalties)
+
+	fmt.Fprintf(w, "\n# Default Search Range (if not specified via flags)\n")
+	fmt.Fprintf(w, "days_to_sync: %d\n", a.DaysToSync)
+}
+
+// writeConfigFile writes the config to a temporary file next to path, checks
+// that it loads and only then renames it over path, so an existing config is
+// never left truncated or invalid
+func writeConfigFile(path string, answers initAnswers) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp) // No-op once renamed
+
+	// CreateTemp already uses 0600; keep it explicit since the file holds the API key
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	WriteInitConfig(f, answers)
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if _, err := LoadConfig(tmp); err != nil {
+		return fmt.Errorf("the generated config is invalid: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// runInit walks through creating config.yml: credentials (verified against the
+// API), preferred devices and penalized uploaders discovered from recent activities
+func runInit(path string, days int, newClient func(apiKey, athleteID string) *IntervalsClient) error {
+	if _, err := os.Stat(path); err == nil {
+		if !confirm(os.Stdout, fmt.Sprintf("%s already exists. Overwrite it?", path), false) {
+			fmt.Println("Nothing written.")
+			return nil
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	fmt.Println("👋 Let's create your config. Find your API key and athlete ID under Settings → Developer Settings on intervals.icu.")
+	var answers initAnswers
+	if answers.APIKey = prompt("API key", os.Getenv("INTERVALS_API_KEY")); answers.APIKey == "" {
+		return fmt.Errorf("an API key is required")
+	}
+	if answers.AthleteID = prompt("Athlete ID (e.g. i12345)", os.Getenv("INTERVALS_ATHLETE_ID")); answers.AthleteID == "" {
+		return fmt.Errorf("an athlete ID is required")
+	}
+
+	client := newClient(answers.APIKey, answers.AthleteID)
+	athlete, err := client.GetAthlete()
+	if err != nil {
+		return fmt.Errorf("verifying the API key and athlete ID: %w", err)
+	}
+	answers.Name = athlete.Name
+	fmt.Printf("✅ Connected as %s\n", valueOr(athlete.Name, answers.AthleteID))
+
+	if days <= 0 {
+		days = defaultInitScanDays
+	}
+	newest := time.Now()
+	oldest := newest.AddDate(0, 0, -days)
+	fmt.Printf("\n🔍 Scanning activities from the last %d days for devices and uploaders...\n", days)
+	activities, err := client.ListActivities(oldest, newest)
+	if err != nil {
+		return fmt.Errorf("fetching activities: %w", err)
+	}
+	report := BuildDeviceReport(activities, NewScoringEngine(&Config{Weights: defaultWeights}))
+	fmt.Printf("   Found %d activities\n", len(activities))
+
+	var devices, uploaders []string
+	for _, st := range report {
+		switch {
+		case st.Field == FieldDevice:
+			devices = append(devices, st.Value)
+		case st.Field == FieldOAuthClient, st.Field == FieldSource && st.Value != "OAUTH_CLIENT":
+			uploaders = append(uploaders, st.Value)
+		}
+	}
+	describe := func(report []DeviceStats, values []string) []string {
+		var described []string
+		for _, v := range values {
+			for _, st := range report {
+				if st.Value == v && (st.Field == FieldDevice || st.Field == FieldSource || st.Field == FieldOAuthClient) {
+					described = append(described, fmt.Sprintf("%s (%d activities, lost %d of %d duplicate groups)", v, st.Activities, st.Lost, st.InGroups))
+					break
+				}
+			}
+		}
+		return described
+	}
+
+	if len(devices) > 0 {
+		fmt.Println("\n📟 Recording devices:")
+		selected := choose("Preferred devices, best first (numbers separated by commas, or none)", describe(report, devices), indexesOf(devices, SuggestDevicePriority(report)))
+		for _, i := range selected {
+			answers.DevicePriority = append(answers.DevicePriority, devices[i])
+		}
+	}
+
+	answers.UploaderPenalties = make(map[string]float64)
+	if len(uploaders) > 0 {
+		// Count the losses the way the chosen devices would rank each group
+		report = BuildDeviceReport(activities, NewScoringEngine(&Config{Weights: defaultWeights, DevicePriority: answers.DevicePriority}))
+		var suggested []string
+		for name := range SuggestUploaderPenalties(report) {
+			suggested = append(suggested, name)
+		}
+		sort.Strings(suggested)
+		fmt.Println("\n🔄 Uploaders and sync tools:")
+		for _, i := range choose("Uploaders to penalize (numbers separated by commas, or none)", describe(report, uploaders), indexesOf(uploaders, suggested)) {
+			answers.UploaderPenalties[uploaders[i]] = defaultUploaderPenalty
+		}
+	}
+
+	fmt.Println()
+	for answers.DaysToSync <= 0 {
+		answers.DaysToSync, _ = strconv.Atoi(prompt("Days to scan on each run", "30"))
+	}
+
+	if err := writeConfigFile(path, answers); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n💾 Wrote %s. Preview the result with: intervals-deduper --dry-run\n", path)
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSelection(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []int
+		wantErr bool
+	}{
+		{"2, 1", []int{1, 0}, false},
+		{"3 1 3", []int{2, 0}, false},
+		{"none", []int{}, false},
+		{"4", nil, true},
+		{"0", nil, true},
+		{"x", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSelection(tt.input, 3)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("parseSelection(%q) = %v, %v; want %v (error %v)", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestRunInit(t *testing.T) {
+	t.Setenv("INTERVALS_API_KEY", "")
+	t.Setenv("INTERVALS_ATHLETE_ID", "")
+	var calls []string
+	server := newFakeIntervals(t, &calls)
+	defer server.Close()
+	newClient := func(apiKey, athleteID string) *IntervalsClient {
+		client := NewIntervalsClient(apiKey, athleteID)
+		client.BaseURL = server.URL
+		return client
+	}
+
+	// Key, athlete ID, keep the suggested device order (head unit first), 14 days
+	saved := stdin
+	stdin = bufio.NewReader(strings.NewReader("secret\ni0\n\n14\n"))
+	defer func() { stdin = saved }()
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := runInit(path, 0, newClient); err != nil {
+		t.Fatalf("runInit error: %v", err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if config.APIKey != "secret" || config.AthleteID != "i0" || config.Name != "Test Athlete" || config.DaysToSync != 14 {
+		t.Errorf("config = %+v", config)
+	}
+	if !reflect.DeepEqual(config.DevicePriority, []string{"Wahoo ELEMNT", "Phone"}) {
+		t.Errorf("device priority = %v", config.DevicePriority)
+	}
+	if config.Weights.GPS != 12 {
+		t.Errorf("weights = %+v; want the default weights", config.Weights)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("config mode = %v; want 0600", info.Mode().Perm())
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("directory has %d entries; want only the config (temporary file left behind?)", len(entries))
+	}
+}
+
+func TestExampleConfigWeights(t *testing.T) {
+	example, err := os.ReadFile("config.example.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	WriteWeights(&b, defaultWeights)
+	if !strings.Contains(string(example), b.String()) {
+		t.Errorf("config.example.yml weights differ from defaultWeights; want:\n%s", b.String())
+	}
+}
@@ -5,17 +5,29 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
-	"math"
 	"os"
-	"sort"
 	"strings"
 	"time"
 )
 
 var Version = "dev"
 
+// stdin is shared by all interactive prompts so buffered input isn't lost
+var stdin = bufio.NewReader(os.Stdin)
+
+// defaultDaysToSync is the scan window when neither config nor flags set one
+const defaultDaysToSync = 30
+
 func main() {
+	// An optional leading command selects a report instead of the de-dup run
+	command := ""
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
 	dryRun := flag.Bool("dry-run", false, "Preview deletions without making changes")
 	interactive := flag.Bool("interactive", false, "Confirm each deletion manually")
 	days := flag.Int("days", 0, "Number of days to sync (overrides config)")
@@ -23,331 +35,287 @@ func main() {
 	endStr := flag.String("end", "", "End date (YYYY-MM-DD)")
 	verbose := flag.Bool("verbose", false, "Show all scanned activities")
 	dump := flag.String("dump", "", "Export all activities to a JSON file (e.g., dump.json)")
+	output := flag.String("output", "human", "Output format: human, json or jsonl")
+	htmlPath := flag.String("html", "", "Write a self-contained HTML review report (e.g., report.html)")
+	addr := flag.String("addr", "127.0.0.1:8080", "Listen address for the serve command")
+	csvPath := flag.String("csv", "", "Export scoring results to a CSV file (e.g., scores.csv)")
+	starter := flag.Bool("starter-config", false, "Print a starter device_priority/uploader_penalties block (devices command)")
+	athleteFilter := flag.String("athlete", "", "Only process this athlete (ID or name) from the athletes list")
 	versionFlag := flag.Bool("version", false, "Show version and exit")
-	flag.Parse()
+
+	// Collect command arguments (e.g. an activity ID) while allowing flags on either side
+	var positional []string
+	for {
+		flag.CommandLine.Parse(args)
+		args = flag.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
 
 	if *versionFlag {
 		fmt.Printf("intervals-deduper version %s\n", Version)
 		return
 	}
 
+	// init creates the config, so it runs before loading one
+	if command == "init" {
+		if err := runInit("config.yml", *days, NewIntervalsClient); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		return
+	}
+
 	config, err := LoadConfig("config.yml")
 	if err != nil {
 		log.Fatalf("Error loading config: %v", err)
 	}
 
-	var newest, oldest time.Time
-	if *startStr != "" {
-		oldest, err = time.Parse("2006-01-02", *startStr)
-		if err != nil {
-			log.Fatalf("Invalid start date: %v", err)
-		}
-		if *endStr != "" {
-			newest, err = time.Parse("2006-01-02", *endStr)
-			if err != nil {
-				log.Fatalf("Invalid end date: %v", err)
-			}
-			// Include the full day for the end date
-			newest = newest.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
-		} else {
-			newest = time.Now()
-		}
-	} else {
-		if *days > 0 {
-			config.DaysToSync = *days
-		} else if config.DaysToSync == 0 {
-			config.DaysToSync = 30 // Sane default
-		}
-		newest = time.Now()
-		oldest = newest.AddDate(0, 0, -config.DaysToSync)
+	athletes, err := config.SelectAthletes(*athleteFilter)
+	if err != nil {
+		log.Fatalf("%v", err)
 	}
 
-	client := NewIntervalsClient(config.APIKey, config.AthleteID)
-	scoring := NewScoringEngine(config)
-
-	fmt.Printf("🔍 Scanning for duplicates from %s to %s...\n", oldest.Format("2006-01-02"), newest.Format("2006-01-02"))
-
-	activities, err := client.ListActivities(oldest, newest)
+	// Structured output owns stdout; the human-readable progress moves to stderr
+	format := valueOr(*output, "human")
+	writer, err := NewOutputWriter(format, os.Stdout)
 	if err != nil {
-		log.Fatalf("Error fetching activities: %v", err)
+		log.Fatalf("%v", err)
+	}
+	var progress io.Writer = os.Stdout
+	if format != "human" {
+		progress = os.Stderr
+	}
+	notifiers, err := NewNotifiers(config.Notifications)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if len(notifiers) > 0 {
+		writer = notifyOutput{writer, notifiers, progress}
+	}
+	switch command {
+	case "", "watch", "webhook":
+		writer = newHistoryOutput(writer, athletes, valueOr(command, "run"), progress)
 	}
 
-	if *dump != "" {
-		fmt.Printf("📦 Fetching details for %d activities and saving to %s...\n", len(activities), *dump)
-		var allDetails []ActivityDetail
-		for i, a := range activities {
-			fmt.Printf("\r   [%d/%d] Fetching %s...", i+1, len(activities), a.ID)
-			detail, err := client.GetActivityDetail(a.ID)
-			if err != nil {
-				fmt.Printf("\n  ⚠️ Failed to fetch details for %s: %v\n", a.ID, err)
-				continue
-			}
-			allDetails = append(allDetails, *detail)
-		}
-		fmt.Printf("\n💾 Writing to %s...\n", *dump)
-
-		data, err := json.MarshalIndent(allDetails, "", "  ")
-		if err != nil {
-			log.Fatalf("Error marshaling data: %v", err)
+	switch command {
+	case "":
+	case "watch":
+		// Every scan covers a rolling window, so fixed dates can't apply
+		if *startStr != "" || *endStr != "" {
+			log.Fatalf("watch re-scans the last days_to_sync days; use --days instead of --start/--end")
 		}
-
-		if err := os.WriteFile(*dump, data, 0644); err != nil {
-			log.Fatalf("Error writing file: %v", err)
+		for _, a := range athletes {
+			if *days > 0 {
+				a.DaysToSync = *days
+			} else if a.DaysToSync == 0 {
+				a.DaysToSync = defaultDaysToSync
+			}
 		}
-		fmt.Println("✅ Done!")
+		runWatch(config, athletes, writer, progress, *dryRun)
+		return
+	case "webhook":
+		runWebhook(config, athletes, writer, progress, *dryRun)
+		return
+	default:
+		runAthleteCommand(command, singleAthlete(athletes), positional, options{
+			days: *days, start: *startStr, end: *endStr, dryRun: *dryRun, addr: *addr, starter: *starter,
+		})
 		return
 	}
 
-	if *verbose {
-		fmt.Printf("📊 Scanned %d total activities\n", len(activities))
-		for _, a := range activities {
-			fmt.Printf("   - [%s] %s (%s)\n", a.ID, a.Name, a.StartDateLocal.Time.Format("2006-01-02 15:04:05"))
-		}
+	if *dump != "" {
+		dumpActivities(singleAthlete(athletes), *days, *startStr, *endStr, *dump)
+		return
 	}
 
-	// Sort activities by StartDateLocal
-	sort.Slice(activities, func(i, j int) bool {
-		return activities[i].StartDateLocal.Time.Before(activities[j].StartDateLocal.Time)
-	})
-
-	// Group activities that are within 30 seconds of each other
-	var groups [][]Activity
-	if len(activities) > 0 {
-		currentGroup := []Activity{activities[0]}
-		for i := 1; i < len(activities); i++ {
-			diff := activities[i].StartDateLocal.Time.Sub(currentGroup[0].StartDateLocal.Time)
-			if diff < 0 {
-				diff = -diff
-			}
+	// Interactive prompts can't be shared between athletes processed at once
+	concurrency := config.Concurrency
+	if *interactive {
+		concurrency = 1
+	}
 
-			if diff <= 30*time.Second {
-				currentGroup = append(currentGroup, activities[i])
-			} else {
-				if len(currentGroup) > 1 {
-					groups = append(groups, currentGroup)
-				}
-				currentGroup = []Activity{activities[i]}
-			}
+	reports := make([]*RunReport, len(athletes))
+	runErr := forEachAthlete(athletes, concurrency, progress, func(i int, a *Config, w io.Writer) error {
+		oldest, newest, err := resolveWindow(a, *days, *startStr, *endStr)
+		if err != nil {
+			return err
 		}
-		if len(currentGroup) > 1 {
-			groups = append(groups, currentGroup)
+		if len(athletes) > 1 {
+			fmt.Fprintf(w, "\n👤 Athlete %s\n", a.Label())
 		}
-	}
+		fmt.Fprintf(w, "🔍 Scanning for duplicates from %s to %s...\n", oldest.Format("2006-01-02"), newest.Format("2006-01-02"))
 
-	for _, group := range groups {
-		first := group[0]
-		fmt.Printf("\n🚩 Found %d suspected duplicates starting around: %s\n", len(group), first.StartDateLocal.Time.Format("2006-01-02 15:04:05"))
+		client := NewIntervalsClient(a.APIKey, a.AthleteID)
+		activities, err := client.ListActivities(oldest, newest)
+		if err != nil {
+			return fmt.Errorf("fetching activities: %w", err)
+		}
 
-		// Fetch details for each to get stream info
-		var details []ActivityDetail
-		for _, a := range group {
-			detail, err := client.GetActivityDetail(a.ID)
-			if err != nil {
-				fmt.Printf("  ⚠️ Failed to fetch details for %s: %v\n", a.ID, err)
-				continue
+		if *verbose {
+			fmt.Fprintf(w, "📊 Scanned %d total activities\n", len(activities))
+			for _, act := range activities {
+				fmt.Fprintf(w, "   - [%s] %s (%s)\n", act.ID, act.Name, act.StartDateLocal.Time.Format("2006-01-02 15:04:05"))
 			}
-			details = append(details, *detail)
 		}
 
-		if len(details) <= 1 {
-			continue
+		run, err := NewRun(a, client, *dryRun, *interactive)
+		if err != nil {
+			return err
 		}
+		run.Output = writer
+		run.Progress = w
+		run.Report.Oldest, run.Report.Newest = oldest, newest
+		run.Process(activities)
+		reports[i] = run.Report
+		return nil
+	})
+	writer.Flush()
 
-		// Score each activity
-		type evaluatedActivity struct {
-			Detail ActivityDetail
-			Score  Scorecard
+	// Athletes that failed have no report
+	var finished []*RunReport
+	for _, r := range reports {
+		if r != nil {
+			finished = append(finished, r)
+		}
+	}
+	if *htmlPath != "" {
+		if err := saveHTMLReport(*htmlPath, finished); err != nil {
+			log.Fatalf("Error writing HTML report: %v", err)
 		}
-		var evaluated []evaluatedActivity
-		for _, d := range details {
-			evaluated = append(evaluated, evaluatedActivity{
-				Detail: d,
-				Score:  scoring.Score(&d),
-			})
+		fmt.Fprintf(progress, "📄 Wrote HTML report to %s\n", *htmlPath)
+	}
+	if *csvPath != "" {
+		if err := saveCSVReport(*csvPath, finished); err != nil {
+			log.Fatalf("Error writing CSV export: %v", err)
 		}
+		fmt.Fprintf(progress, "📄 Wrote CSV export to %s\n", *csvPath)
+	}
+	if runErr != nil {
+		log.Fatalf("Error: %v", runErr)
+	}
+}
 
-		// Sort by Score DESC, then Updated timestamp DESC, then Created timestamp DESC
-		sort.Slice(evaluated, func(i, j int) bool {
-			if evaluated[i].Score.Total != evaluated[j].Score.Total {
-				return evaluated[i].Score.Total > evaluated[j].Score.Total
-			}
-			if !evaluated[i].Detail.Updated.Equal(evaluated[j].Detail.Updated.Time) {
-				return evaluated[i].Detail.Updated.After(evaluated[j].Detail.Updated.Time)
-			}
-			return evaluated[i].Detail.CreatedAt.After(evaluated[j].Detail.CreatedAt.Time)
-		})
+// options are the CLI flags used by the single-athlete commands
+type options struct {
+	days       int
+	start, end string
+	dryRun     bool
+	addr       string
+	starter    bool
+}
 
-		winner := evaluated[0]
-		losers := evaluated[1:]
+// runAthleteCommand runs a command that works on a single athlete
+func runAthleteCommand(command string, config *Config, positional []string, opts options) {
+	oldest, newest, err := resolveWindow(config, opts.days, opts.start, opts.end)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	client := NewIntervalsClient(config.APIKey, config.AthleteID)
+	scoring := NewScoringEngine(config)
 
-		winnerSystem := winner.Detail.DeviceName
-		winnerSrc := winner.Detail.Source
-		if winnerSrc == "OAUTH_CLIENT" && winner.Detail.OAuthClientName != "" {
-			winnerSrc = winner.Detail.OAuthClientName
+	switch command {
+	case "sensors":
+		runSensorsReport(client, scoring, oldest, newest)
+	case "devices":
+		runDevicesReport(client, scoring, oldest, newest, opts.starter)
+	case "tune":
+		runTune(config)
+	case "explain":
+		if len(positional) != 1 {
+			log.Fatalf("Usage: intervals-deduper explain <activity-id>")
 		}
-		if winnerSrc != "" && winnerSrc != "OAUTH_CLIENT" && !strings.Contains(strings.ToLower(winner.Detail.DeviceName), strings.ToLower(winnerSrc)) {
-			winnerSystem = fmt.Sprintf("%s / %s", winner.Detail.DeviceName, winnerSrc)
+		runExplain(client, scoring, positional[0])
+	case "ignore":
+		runIgnore(client, config, positional)
+	case "history":
+		oldest, newest = historyWindow(opts, oldest, newest)
+		runHistory(config, positional, oldest, newest)
+	case "stats":
+		oldest, newest = historyWindow(opts, oldest, newest)
+		runStats(config, oldest, newest)
+	case "serve":
+		run, err := NewRun(config, client, opts.dryRun, false)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
 		}
-
-		fmt.Printf("  🏆 Winner: [%s] (ID: %s, Score: %.2f) - %s (%s, %s)\n",
-			winnerSystem, winner.Detail.ID, winner.Score.Total, winner.Detail.Name,
-			formatDistance(winner.Detail.Distance), formatDuration(winner.Detail.MovingTime))
-		for _, r := range winner.Score.Reasonings {
-			fmt.Printf("    - %s\n", r)
+		run.Report.Oldest, run.Report.Newest = oldest, newest
+		runServe(run, oldest, newest, opts.addr)
+	case "review":
+		run, err := NewRun(config, client, opts.dryRun, false)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
 		}
-
-		// --- Name Adoption Logic ---
-		if scoring.IsGenericName(winner.Detail.Name, winner.Detail.Type) {
-			var candidateNames []string
-			for _, loser := range losers {
-				candidateNames = append(candidateNames, loser.Detail.Name)
-			}
-			bestName := scoring.RankCandidateNames(candidateNames, winner.Detail.Type)
-
-			if bestName != "" {
-				adoptConfirmed := !*interactive
-				if *interactive {
-					fmt.Printf("    Adopt descriptive name \"%s\" for %s? [Y/n]: ", bestName, winner.Detail.ID)
-					reader := bufio.NewReader(os.Stdin)
-					response, _ := reader.ReadString('\n')
-					if strings.ToLower(strings.TrimSpace(response)) == "n" {
-						adoptConfirmed = false
-					}
-				}
-
-				if adoptConfirmed {
-					if *dryRun {
-						fmt.Printf("    [DRY RUN] Would adopt name \"%s\" for %s\n", bestName, winner.Detail.ID)
-					} else {
-						fmt.Printf("    Adopting name \"%s\"...\n", bestName)
-						updates := map[string]interface{}{"name": bestName}
-						if err := client.UpdateActivity(winner.Detail.ID, updates); err != nil {
-							fmt.Printf("    ❌ Error updating name: %v\n", err)
-						} else {
-							fmt.Printf("    ✅ Name updated\n")
-						}
-					}
-				}
-			}
+		run.Report.Oldest, run.Report.Newest = oldest, newest
+		activities, err := client.ListActivities(oldest, newest)
+		if err != nil {
+			log.Fatalf("Error fetching activities: %v", err)
 		}
-
-		// --- Metadata Adoption Logic (Feel, RPE, Description) ---
-		metaUpdates := make(map[string]interface{})
-		metaReasons := []string{}
-
-		for _, loser := range losers {
-			// Migrate Feel
-			if winner.Detail.Feel == 0 && loser.Detail.Feel > 0 {
-				metaUpdates["feel"] = loser.Detail.Feel
-				metaReasons = append(metaReasons, fmt.Sprintf("Feel: %d", loser.Detail.Feel))
-				winner.Detail.Feel = loser.Detail.Feel // Clear so we don't pick it up again
-			}
-			// Migrate RPE
-			if winner.Detail.RPE == 0 && loser.Detail.RPE > 0 {
-				metaUpdates["icu_rpe"] = loser.Detail.RPE
-				metaReasons = append(metaReasons, fmt.Sprintf("RPE: %d", loser.Detail.RPE))
-				winner.Detail.RPE = loser.Detail.RPE
-			}
-			// Migrate Description
-			if strings.TrimSpace(winner.Detail.Description) == "" && strings.TrimSpace(loser.Detail.Description) != "" {
-				desc := strings.TrimSpace(loser.Detail.Description)
-				metaUpdates["description"] = desc
-				metaReasons = append(metaReasons, "Description")
-				winner.Detail.Description = desc
-			}
+		fmt.Printf("🔍 Scoring duplicates from %s to %s...\n", oldest.Format("2006-01-02"), newest.Format("2006-01-02"))
+		if err := runReviewTUI(run, run.ReviewGroups(activities)); err != nil {
+			log.Fatalf("Error: %v", err)
 		}
+	default:
+		log.Fatalf("Unknown command: %s", command)
+	}
+}
 
-		if len(metaUpdates) > 0 {
-			migrateConfirmed := !*interactive
-			msg := strings.Join(metaReasons, ", ")
-			if *interactive {
-				fmt.Printf("    Adopt metadata (%s) for %s? [Y/n]: ", msg, winner.Detail.ID)
-				reader := bufio.NewReader(os.Stdin)
-				response, _ := reader.ReadString('\n')
-				if strings.ToLower(strings.TrimSpace(response)) == "n" {
-					migrateConfirmed = false
-				}
-			}
+// dumpActivities exports the details of every activity in the window to a JSON file
+func dumpActivities(config *Config, days int, start, end, path string) {
+	oldest, newest, err := resolveWindow(config, days, start, end)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	client := NewIntervalsClient(config.APIKey, config.AthleteID)
+	activities, err := client.ListActivities(oldest, newest)
+	if err != nil {
+		log.Fatalf("Error fetching activities: %v", err)
+	}
 
-			if migrateConfirmed {
-				if *dryRun {
-					fmt.Printf("    [DRY RUN] Would adopt metadata (%s) for %s\n", msg, winner.Detail.ID)
-				} else {
-					fmt.Printf("    Adopting metadata (%s)...\n", msg)
-					if err := client.UpdateActivity(winner.Detail.ID, metaUpdates); err != nil {
-						fmt.Printf("    ❌ Error updating metadata: %v\n", err)
-					} else {
-						fmt.Printf("    ✅ Metadata updated\n")
-					}
-				}
-			}
+	fmt.Printf("📦 Fetching details for %d activities and saving to %s...\n", len(activities), path)
+	var allDetails []ActivityDetail
+	for i, a := range activities {
+		fmt.Printf("\r   [%d/%d] Fetching %s...", i+1, len(activities), a.ID)
+		detail, err := client.GetActivityDetail(a.ID)
+		if err != nil {
+			fmt.Printf("\n  ⚠️ Failed to fetch details for %s: %v\n", a.ID, err)
+			continue
 		}
+		allDetails = append(allDetails, *detail)
+	}
+	fmt.Printf("\n💾 Writing to %s...\n", path)
 
-		for _, loser := range losers {
-			loserSystem := loser.Detail.DeviceName
-			loserSrc := loser.Detail.Source
-			if loserSrc == "OAUTH_CLIENT" && loser.Detail.OAuthClientName != "" {
-				loserSrc = loser.Detail.OAuthClientName
-			}
-			if loserSrc != "" && loserSrc != "OAUTH_CLIENT" && !strings.Contains(strings.ToLower(loser.Detail.DeviceName), strings.ToLower(loserSrc)) {
-				loserSystem = fmt.Sprintf("%s / %s", loser.Detail.DeviceName, loserSrc)
-			}
-			distDiff := math.Abs(winner.Detail.Distance-loser.Detail.Distance) / math.Max(winner.Detail.Distance, 1.0)
-			timeDiff := math.Abs(float64(winner.Detail.MovingTime-loser.Detail.MovingTime)) / math.Max(float64(winner.Detail.MovingTime), 1.0)
-
-			isMismatch := distDiff > 0.5 || timeDiff > 0.25
-
-			warnings := ""
-			if distDiff > 0.5 {
-				warnings += " ⚠️ [DIST MISMATCH]"
-			}
-			if timeDiff > 0.25 {
-				warnings += " ⚠️ [TIME MISMATCH]"
-			}
-
-			if isMismatch {
-				fmt.Printf("  ⚠️  Mismatch: [%s] (ID: %s, Score: %.2f) - %s (%s, %s)%s\n",
-					loserSystem, loser.Detail.ID, loser.Score.Total, loser.Detail.Name,
-					formatDistance(loser.Detail.Distance), formatDuration(loser.Detail.MovingTime), warnings)
-				fmt.Printf("    ⏭️  Skipping deletion recommendation for %s due to size difference.\n", loser.Detail.ID)
-				continue
-			}
-
-			fmt.Printf("  🗑️  To Delete: [%s] (ID: %s, Score: %.2f) - %s (%s, %s)\n",
-				loserSystem, loser.Detail.ID, loser.Score.Total, loser.Detail.Name,
-				formatDistance(loser.Detail.Distance), formatDuration(loser.Detail.MovingTime))
-
-			for _, r := range loser.Score.Reasonings {
-				fmt.Printf("    - %s\n", r)
-			}
+	data, err := json.MarshalIndent(allDetails, "", "  ")
+	if err != nil {
+		log.Fatalf("Error marshaling data: %v", err)
+	}
 
-			deleteConfirmed := !*interactive
-			if *interactive {
-				fmt.Printf("    Confirm deletion of %s? [y/N]: ", loser.Detail.ID)
-				reader := bufio.NewReader(os.Stdin)
-				response, _ := reader.ReadString('\n')
-				if strings.ToLower(strings.TrimSpace(response)) == "y" {
-					deleteConfirmed = true
-				}
-			}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		log.Fatalf("Error writing file: %v", err)
+	}
+	fmt.Println("✅ Done!")
+}
 
-			if deleteConfirmed {
-				if *dryRun {
-					fmt.Printf("    [DRY RUN] Would delete %s\n", loser.Detail.ID)
-				} else {
-					fmt.Printf("    Deleting %s...\n", loser.Detail.ID)
-					if err := client.DeleteActivity(loser.Detail.ID); err != nil {
-						fmt.Printf("    ❌ Error deleting %s: %v\n", loser.Detail.ID, err)
-					} else {
-						fmt.Printf("    ✅ Deleted %s\n", loser.Detail.ID)
-					}
-				}
-			} else {
-				fmt.Printf("    ⏭️  Skipped deletion of %s\n", loser.Detail.ID)
-			}
-		}
+// confirm prompts on stdin and returns the answer, using def for an empty response
+func confirm(w io.Writer, prompt string, def bool) bool {
+	if def {
+		fmt.Fprintf(w, "%s [Y/n]: ", prompt)
+	} else {
+		fmt.Fprintf(w, "%s [y/N]: ", prompt)
+	}
+	response, _ := stdin.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(response)) {
+	case "y":
+		return true
+	case "n":
+		return false
+	default:
+		return def
 	}
 }
+
 func formatDuration(seconds int) string {
 	h := seconds / 3600
 	m := (seconds % 3600) / 60
@@ -361,3 +329,36 @@ func formatDuration(seconds int) string {
 func formatDistance(meters float64) string {
 	return fmt.Sprintf("%.1fkm", meters/1000.0)
 }
+
+// resolveWindow determines the scan window from the CLI flags, falling back to
+// the configured (or default) number of days.
+func resolveWindow(config *Config, days int, startStr, endStr string) (time.Time, time.Time, error) {
+	var oldest, newest time.Time
+	var err error
+	if startStr != "" {
+		oldest, err = time.Parse("2006-01-02", startStr)
+		if err != nil {
+			return oldest, newest, fmt.Errorf("invalid start date: %w", err)
+		}
+		if endStr != "" {
+			newest, err = time.Parse("2006-01-02", endStr)
+			if err != nil {
+				return oldest, newest, fmt.Errorf("invalid end date: %w", err)
+			}
+			// Include the full day for the end date
+			newest = newest.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+		} else {
+			newest = time.Now()
+		}
+		return oldest, newest, nil
+	}
+
+	if days > 0 {
+		config.DaysToSync = days
+	} else if config.DaysToSync == 0 {
+		config.DaysToSync = defaultDaysToSync
+	}
+	newest = time.Now()
+	oldest = newest.AddDate(0, 0, -config.DaysToSync)
+	return oldest, newest, nil
+}
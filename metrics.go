@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// apiLatencyBuckets are the upper bounds (seconds) of the API latency histogram
+var apiLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// histogram is a cumulative Prometheus-style histogram
+type histogram struct {
+	counts []uint64 // One per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+func (h *histogram) observe(v float64) {
+	for i, le := range apiLatencyBuckets {
+		if v <= le {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// metricFamily is one metric name with its series keyed by rendered labels
+type metricFamily struct {
+	help       string
+	kind       string // "counter", "gauge" or "histogram"
+	values     map[string]float64
+	histograms map[string]*histogram
+}
+
+// Metrics collects counters for long-running modes and renders them in the
+// Prometheus text exposition format
+type Metrics struct {
+	mu       sync.Mutex
+	families map[string]*metricFamily
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{families: make(map[string]*metricFamily)}
+	m.register("deduper_activities_scanned_total", "counter", "Activities listed by de-dup runs.")
+	m.register("deduper_duplicate_groups_total", "counter", "Duplicate groups found (excluding ignored groups).")
+	m.register("deduper_split_recordings_total", "counter", "Split recordings found.")
+	m.register("deduper_actions_total", "counter", "Deletions, updates and uploads by result (ok, error, dry_run, declined).")
+	m.register("deduper_mismatch_skips_total", "counter", "Losers kept because their distance or moving time differed from the winner's.")
+	m.register("deduper_protected_total", "counter", "Losers kept because they matched a protect rule.")
+	m.register("deduper_runs_total", "counter", "Completed de-dup runs.")
+	m.register("deduper_run_errors_total", "counter", "De-dup runs that failed before processing, e.g. when listing activities failed.")
+	m.register("deduper_last_success_timestamp_seconds", "gauge", "Unix time of the last completed de-dup run.")
+	m.register("deduper_api_requests_total", "counter", "Intervals.icu API requests by endpoint and status code (\"error\" when no response).")
+	m.register("deduper_api_request_duration_seconds", "histogram", "Intervals.icu API request latency by endpoint.")
+	return m
+}
+
+func (m *Metrics) register(name, kind, help string) {
+	m.families[name] = &metricFamily{help: help, kind: kind, values: make(map[string]float64), histograms: make(map[string]*histogram)}
+}
+
+// labels renders label pairs as name="value",... with values escaped
+func labels(pairs ...string) string {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	var parts []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, pairs[i]+`="`+escape.Replace(pairs[i+1])+`"`)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (m *Metrics) add(name, labels string, v float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.families[name].values[labels] += v
+}
+
+func (m *Metrics) set(name, labels string, v float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.families[name].values[labels] = v
+}
+
+func (m *Metrics) observe(name, labels string, v float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := m.families[name].histograms[labels]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(apiLatencyBuckets))}
+		m.families[name].histograms[labels] = h
+	}
+	h.observe(v)
+}
+
+// RecordRun counts the outcome of a finished run
+func (m *Metrics) RecordRun(r *RunReport, at time.Time) {
+	athlete := labels("athlete", r.AthleteID)
+	s := r.Summary()
+	m.add("deduper_activities_scanned_total", athlete, float64(s.Scanned))
+	m.add("deduper_duplicate_groups_total", athlete, float64(s.Groups))
+	m.add("deduper_split_recordings_total", athlete, float64(s.Splits))
+	m.add("deduper_mismatch_skips_total", athlete, float64(s.MismatchSkipped))
+	m.add("deduper_protected_total", athlete, float64(s.Protected))
+	m.add("deduper_runs_total", athlete, 1)
+	m.set("deduper_last_success_timestamp_seconds", athlete, float64(at.Unix()))
+
+	var actions []ActionReport
+	for _, g := range r.Groups {
+		actions = append(actions, g.Actions...)
+	}
+	for _, sp := range r.Splits {
+		actions = append(actions, sp.Actions...)
+	}
+	for _, a := range actions {
+		m.add("deduper_actions_total", labels("athlete", r.AthleteID, "action", a.Action, "result", a.Status), 1)
+	}
+}
+
+// RecordRunError counts a run that failed before it could process activities
+func (m *Metrics) RecordRunError(athleteID string) {
+	m.add("deduper_run_errors_total", labels("athlete", athleteID), 1)
+}
+
+// RecordAPI counts an API request; status is 0 when no response was received
+func (m *Metrics) RecordAPI(endpoint string, status int, elapsed time.Duration) {
+	code := "error"
+	if status != 0 {
+		code = strconv.Itoa(status)
+	}
+	m.add("deduper_api_requests_total", labels("endpoint", endpoint, "code", code), 1)
+	m.observe("deduper_api_request_duration_seconds", labels("endpoint", endpoint), elapsed.Seconds())
+}
+
+// WriteTo renders every metric in the Prometheus text exposition format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	names := make([]string, 0, len(m.families))
+	for name := range m.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := m.families[name]
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, f.help, name, f.kind)
+		for _, l := range sortedKeys(f.values) {
+			fmt.Fprintf(&b, "%s%s %s\n", name, braces(l), formatMetric(f.values[l]))
+		}
+		for _, l := range sortedKeys(f.histograms) {
+			h := f.histograms[l]
+			var cumulative uint64
+			for i, le := range apiLatencyBuckets {
+				cumulative += h.counts[i]
+				fmt.Fprintf(&b, "%s_bucket%s %d\n", name, braces(joinLabels(l, labels("le", formatMetric(le)))), cumulative)
+			}
+			fmt.Fprintf(&b, "%s_bucket%s %d\n", name, braces(joinLabels(l, labels("le", "+Inf"))), h.count)
+			fmt.Fprintf(&b, "%s_sum%s %s\n", name, braces(l), formatMetric(h.sum))
+			fmt.Fprintf(&b, "%s_count%s %d\n", name, braces(l), h.count)
+		}
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func braces(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+func joinLabels(a, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + "," + b
+}
+
+func formatMetric(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Instrument records the latency and status of every request the client makes
+func (m *Metrics) Instrument(client *IntervalsClient) {
+	next := client.HTTPClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	client.HTTPClient.Transport = &metricsTransport{metrics: m, next: next}
+}
+
+// metricsTransport times requests to the Intervals.icu API
+type metricsTransport struct {
+	metrics *Metrics
+	next    http.RoundTripper
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	status := 0
+	if err == nil {
+		status = resp.StatusCode
+	}
+	t.metrics.RecordAPI(apiEndpoint(req.Method, req.URL.Path), status, time.Since(start))
+	return resp, err
+}
+
+// apiEndpoint replaces athlete and activity IDs in a request path so requests
+// are grouped by endpoint, e.g. "GET /api/v1/activity/{id}/streams"
+func apiEndpoint(method, path string) string {
+	segments := strings.Split(path, "/")
+	for i := 1; i < len(segments); i++ {
+		if segments[i-1] == "athlete" || segments[i-1] == "activity" {
+			segments[i] = "{id}"
+		}
+	}
+	return method + " " + strings.Join(segments, "/")
+}
+
+// metricsOutput records every finished run before passing it on
+type metricsOutput struct {
+	OutputWriter
+	metrics *Metrics
+}
+
+func (o metricsOutput) Finish(r *RunReport) {
+	o.metrics.RecordRun(r, time.Now())
+	o.OutputWriter.Finish(r)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPIEndpoint(t *testing.T) {
+	tests := []struct {
+		method, path, want string
+	}{
+		{"GET", "/api/v1/athlete/i123/activities", "GET /api/v1/athlete/{id}/activities"},
+		{"DELETE", "/api/v1/activity/i456", "DELETE /api/v1/activity/{id}"},
+		{"GET", "/api/v1/activity/i456/streams", "GET /api/v1/activity/{id}/streams"},
+	}
+	for _, tt := range tests {
+		if got := apiEndpoint(tt.method, tt.path); got != tt.want {
+			t.Errorf("apiEndpoint(%q, %q) = %q; want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMetricsExposition(t *testing.T) {
+	m := NewMetrics()
+	m.RecordRun(&RunReport{
+		AthleteID: "i1",
+		Scanned:   5,
+		Groups: []*GroupReport{{
+			Members: []*MemberReport{{Plan: PlanKeep}, {Plan: PlanMismatch}},
+			Actions: []ActionReport{{Action: AuditDelete, Status: StatusOK}, {Action: AuditUpdateName, Status: StatusError}},
+		}},
+	}, time.Unix(1700000000, 0))
+	m.RecordAPI("GET /api/v1/activity/{id}", 200, 300*time.Millisecond)
+	m.RecordAPI("GET /api/v1/activity/{id}", 0, time.Second)
+
+	var b strings.Builder
+	m.WriteTo(&b)
+	out := b.String()
+
+	for _, want := range []string{
+		"# TYPE deduper_activities_scanned_total counter\n",
+		`deduper_activities_scanned_total{athlete="i1"} 5` + "\n",
+		`deduper_duplicate_groups_total{athlete="i1"} 1` + "\n",
+		`deduper_mismatch_skips_total{athlete="i1"} 1` + "\n",
+		`deduper_actions_total{athlete="i1",action="delete",result="ok"} 1` + "\n",
+		`deduper_actions_total{athlete="i1",action="update_name",result="error"} 1` + "\n",
+		`deduper_last_success_timestamp_seconds{athlete="i1"} 1.7e+09` + "\n",
+		`deduper_api_requests_total{endpoint="GET /api/v1/activity/{id}",code="200"} 1` + "\n",
+		`deduper_api_requests_total{endpoint="GET /api/v1/activity/{id}",code="error"} 1` + "\n",
+		`deduper_api_request_duration_seconds_bucket{endpoint="GET /api/v1/activity/{id}",le="0.25"} 0` + "\n",
+		`deduper_api_request_duration_seconds_bucket{endpoint="GET /api/v1/activity/{id}",le="0.5"} 1` + "\n",
+		`deduper_api_request_duration_seconds_bucket{endpoint="GET /api/v1/activity/{id}",le="+Inf"} 2` + "\n",
+		`deduper_api_request_duration_seconds_sum{endpoint="GET /api/v1/activity/{id}"} 1.3` + "\n",
+		`deduper_api_request_duration_seconds_count{endpoint="GET /api/v1/activity/{id}"} 2` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("exposition missing %q", want)
+		}
+	}
+}
+
+func TestMetricsInstrument(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	m := NewMetrics()
+	client := NewIntervalsClient("key", "i1")
+	client.BaseURL = server.URL
+	m.Instrument(client)
+	client.DeleteActivity("i9")
+
+	var b strings.Builder
+	m.WriteTo(&b)
+	want := `deduper_api_requests_total{endpoint="DELETE /api/v1/activity/{id}",code="404"} 1`
+	if !strings.Contains(b.String(), want) {
+		t.Errorf("exposition missing %q", want)
+	}
+}
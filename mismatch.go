@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// MismatchPolicy decides what happens to a loser whose distance or moving time
+// differs too much from the winner's
+type MismatchPolicy string
+
+const (
+	MismatchSkip  MismatchPolicy = "skip"  // Keep both, no deletion or metadata adoption
+	MismatchFlag  MismatchPolicy = "flag"  // Keep both and flag the loser for review
+	MismatchAdopt MismatchPolicy = "adopt" // Keep both but adopt metadata from the loser
+	MismatchSplit MismatchPolicy = "split" // Keep both and hand the loser to split recording detection
+)
+
+// Plan is the member plan for a loser kept because of a mismatch
+func (p MismatchPolicy) Plan() string {
+	switch p {
+	case MismatchFlag:
+		return PlanFlagged
+	case MismatchAdopt:
+		return PlanAdoptOnly
+	case MismatchSplit:
+		return PlanSplit
+	}
+	return PlanMismatch
+}
+
+// Default thresholds, as fractions of the winner's distance/moving time
+const (
+	defaultDistanceMismatch = 0.5
+	defaultTimeMismatch     = 0.25
+)
+
+// MismatchRule holds the thresholds and policy for one activity type
+type MismatchRule struct {
+	Distance float64        `yaml:"distance"`
+	Time     float64        `yaml:"time"`
+	Policy   MismatchPolicy `yaml:"policy"`
+}
+
+// MismatchConfig holds the default rule plus per-activity-type overrides
+type MismatchConfig struct {
+	MismatchRule `yaml:",inline"`
+	Types        map[string]MismatchRule `yaml:"types"`
+}
+
+// MismatchRuleFor resolves the rule for an activity type. Unset fields in a
+// type override inherit from the defaults.
+func (c *Config) MismatchRuleFor(activityType string) MismatchRule {
+	rule := MismatchRule{
+		Distance: defaultDistanceMismatch,
+		Time:     defaultTimeMismatch,
+		Policy:   MismatchSkip,
+	}
+	rule = mergeMismatchRule(rule, c.Mismatch.MismatchRule)
+
+	for t, override := range c.Mismatch.Types {
+		if strings.EqualFold(t, activityType) {
+			rule = mergeMismatchRule(rule, override)
+			break
+		}
+	}
+
+	return rule
+}
+
+func mergeMismatchRule(base, override MismatchRule) MismatchRule {
+	if override.Distance > 0 {
+		base.Distance = override.Distance
+	}
+	if override.Time > 0 {
+		base.Time = override.Time
+	}
+	if override.Policy != "" {
+		base.Policy = override.Policy
+	}
+	return base
+}
+
+// Validate checks the configured policies are known
+func (c MismatchConfig) Validate() error {
+	rules := map[string]MismatchRule{"default": c.MismatchRule}
+	for t, r := range c.Types {
+		rules[t] = r
+	}
+	for name, r := range rules {
+		switch r.Policy {
+		case "", MismatchSkip, MismatchFlag, MismatchAdopt, MismatchSplit:
+		default:
+			return fmt.Errorf("unknown mismatch policy %q for %s (expected skip, flag, adopt or split)", r.Policy, name)
+		}
+	}
+	return nil
+}
+
+// MismatchResult records how a loser's size compares with the winner's
+type MismatchResult struct {
+	DistDiff     float64
+	TimeDiff     float64
+	DistMismatch bool
+	TimeMismatch bool
+}
+
+// IsMismatch reports whether either threshold was exceeded
+func (m MismatchResult) IsMismatch() bool {
+	return m.DistMismatch || m.TimeMismatch
+}
+
+// Warnings formats the mismatch flags for console output
+func (m MismatchResult) Warnings() string {
+	warnings := ""
+	if m.DistMismatch {
+		warnings += " ⚠️ [DIST MISMATCH]"
+	}
+	if m.TimeMismatch {
+		warnings += " ⚠️ [TIME MISMATCH]"
+	}
+	return warnings
+}
+
+// CheckMismatch compares a loser's distance and moving time against the winner's
+func CheckMismatch(rule MismatchRule, winner, loser *ActivityDetail) MismatchResult {
+	distDiff := math.Abs(winner.Distance-loser.Distance) / math.Max(winner.Distance, 1.0)
+	timeDiff := math.Abs(float64(winner.MovingTime-loser.MovingTime)) / math.Max(float64(winner.MovingTime), 1.0)
+
+	return MismatchResult{
+		DistDiff:     distDiff,
+		TimeDiff:     timeDiff,
+		DistMismatch: distDiff > rule.Distance,
+		TimeMismatch: timeDiff > rule.Time,
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMismatchRuleFor(t *testing.T) {
+	config := &Config{
+		Mismatch: MismatchConfig{
+			MismatchRule: MismatchRule{Time: 0.3},
+			Types: map[string]MismatchRule{
+				"Run": {Distance: 0.1, Policy: MismatchFlag},
+			},
+		},
+	}
+
+	def := config.MismatchRuleFor("Ride")
+	if def.Distance != defaultDistanceMismatch || def.Time != 0.3 || def.Policy != MismatchSkip {
+		t.Errorf("default rule = %+v", def)
+	}
+
+	run := config.MismatchRuleFor("run")
+	if run.Distance != 0.1 || run.Time != 0.3 || run.Policy != MismatchFlag {
+		t.Errorf("run rule = %+v", run)
+	}
+}
+
+func TestCheckMismatch(t *testing.T) {
+	rule := MismatchRule{Distance: 0.5, Time: 0.25}
+	winner := &ActivityDetail{Activity: Activity{Distance: 40000, MovingTime: 5400}}
+
+	tests := []struct {
+		distance float64
+		moving   int
+		wantDist bool
+		wantTime bool
+	}{
+		{39000, 5300, false, false},
+		{10000, 5300, true, false},
+		{39000, 2400, false, true},
+	}
+
+	for _, tt := range tests {
+		loser := &ActivityDetail{Activity: Activity{Distance: tt.distance, MovingTime: tt.moving}}
+		got := CheckMismatch(rule, winner, loser)
+		if got.DistMismatch != tt.wantDist || got.TimeMismatch != tt.wantTime {
+			t.Errorf("CheckMismatch(%.0f, %d) = %+v; want dist=%v time=%v", tt.distance, tt.moving, got, tt.wantDist, tt.wantTime)
+		}
+	}
+}
+
+func TestMismatchConfigValidate(t *testing.T) {
+	if err := (MismatchConfig{MismatchRule: MismatchRule{Policy: "adopt"}}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	bad := MismatchConfig{Types: map[string]MismatchRule{"Run": {Policy: "delete"}}}
+	if err := bad.Validate(); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+}
+
+func TestRunMismatchPolicies(t *testing.T) {
+	tests := []struct {
+		policy MismatchPolicy
+		plan   string
+		calls  []string
+	}{
+		// Flagged losers are never deleted
+		{MismatchFlag, PlanFlagged, nil},
+		{MismatchSkip, PlanMismatch, nil},
+		// Split candidates are handed to split detection, which deletes them as
+		// fragments of the winner when delete_fragments is on
+		{MismatchSplit, PlanSplit, []string{"DELETE i2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			var calls []string
+			server := newFakeIntervals(t, &calls)
+			defer server.Close()
+
+			run := newTestRun(t, server, false)
+			// i2 is 0.5% shorter than i1
+			run.Config.Mismatch = MismatchConfig{MismatchRule: MismatchRule{Distance: 0.001, Policy: tt.policy}}
+			run.Config.Splits.DeleteFragments = true
+
+			activities, err := run.Client.ListActivities(run.Report.Oldest, run.Report.Newest)
+			if err != nil {
+				t.Fatalf("ListActivities error: %v", err)
+			}
+			run.Process(activities)
+
+			if strings.Join(calls, "\n") != strings.Join(tt.calls, "\n") {
+				t.Errorf("calls = %v; want %v", calls, tt.calls)
+			}
+			if m := run.Report.Groups[0].Member("i2"); m == nil || m.Plan != tt.plan {
+				t.Errorf("i2 plan = %+v; want %s", m, tt.plan)
+			}
+		})
+	}
+}
+
+func TestRunSplitPolicyLongerLoser(t *testing.T) {
+	var calls []string
+	server := newFakeIntervals(t, &calls)
+	defer server.Close()
+
+	run := newTestRun(t, server, false)
+	// The shorter phone recording (i2) wins, so the longer i1 can't be its fragment
+	run.Config.Weights = Weights{}
+	run.Config.DevicePriority = []string{"Phone"}
+	run.Config.Mismatch = MismatchConfig{MismatchRule: MismatchRule{Distance: 0.001, Policy: MismatchSplit}}
+	run.Config.Splits.DeleteFragments = true
+
+	activities, err := run.Client.ListActivities(run.Report.Oldest, run.Report.Newest)
+	if err != nil {
+		t.Fatalf("ListActivities error: %v", err)
+	}
+	run.Process(activities)
+
+	if len(calls) != 0 {
+		t.Errorf("calls = %v; want nothing deleted", calls)
+	}
+	if m := run.Report.Groups[0].Member("i1"); m == nil || m.Plan != PlanFlagged {
+		t.Errorf("i1 = %+v; want flagged", m)
+	}
+	if len(run.Report.Splits) != 0 {
+		t.Errorf("splits = %+v; want none", run.Report.Splits)
+	}
+}
@@ -8,12 +8,32 @@ import (
 
 // Config represents the application configuration
 type Config struct {
-	APIKey            string             `yaml:"api_key"`
-	AthleteID         string             `yaml:"athlete_id"`
-	Weights           Weights            `yaml:"weights"`
-	DevicePriority    []string           `yaml:"device_priority"`
-	UploaderPenalties map[string]float64 `yaml:"uploader_penalties"`
-	DaysToSync        int                `yaml:"days_to_sync"`
+	APIKey             string             `yaml:"api_key"`
+	AthleteID          string             `yaml:"athlete_id"`
+	Name               string             `yaml:"name"` // Athlete name shown in reports
+	Weights            Weights            `yaml:"weights"`
+	DevicePriority     []string           `yaml:"device_priority"`
+	UploaderPenalties  map[string]float64 `yaml:"uploader_penalties"`
+	DaysToSync         int                `yaml:"days_to_sync"`
+	BarometricDevices  []string           `yaml:"barometric_devices"`
+	PowerMeterPriority []string           `yaml:"power_meter_priority"`
+	Mismatch           MismatchConfig     `yaml:"mismatch"`
+	Splits             SplitConfig        `yaml:"splits"`
+	DecisionsPath      string             `yaml:"decisions_file"`
+	TieBreakers        []string           `yaml:"tie_breakers"`
+	SourcePriority     []string           `yaml:"source_priority"`
+	Protect            ProtectConfig      `yaml:"protect"`
+	IgnorePath         string             `yaml:"ignore_file"`
+	AuditPath          string             `yaml:"audit_log"`
+	HistoryPath        string             `yaml:"history_file"` // Run history (JSON lines) for the history and stats commands
+	Watch              WatchConfig        `yaml:"watch"`
+	Webhook            WebhookConfig      `yaml:"webhook"`
+	Notifications      []NotifierConfig   `yaml:"notifications"` // Run summaries sent after unattended runs
+
+	// Multiple athletes (e.g. for a coach). Each entry is parsed over the shared
+	// settings above; api_key falls back to the top-level (coach) key.
+	Athletes    []*Config `yaml:"-"`
+	Concurrency int       `yaml:"concurrency"` // Athletes processed at once (default 1)
 }
 
 // Weights represents the importance of different metrics for heuristic scoring
@@ -26,6 +46,62 @@ type Weights struct {
 	RPE          float64 `yaml:"rpe"`         // Bonus for presence of RPE/Feel
 	Manual       float64 `yaml:"manual"`      // Bonus for notes/description
 	CustomName   float64 `yaml:"custom_name"` // Bonus for non-generic names
+	Altitude     float64 `yaml:"altitude"`    // Bonus for barometric (vs GPS-derived) elevation
+	LowBattery   float64 `yaml:"low_battery"` // Penalty when the power meter battery is low (doubled when critical)
+}
+
+// defaultWeights are the weights written by init and shown in config.example.yml
+var defaultWeights = Weights{
+	GPS:          12,
+	HeartRate:    5,
+	Power:        10,
+	Cadence:      2,
+	SamplingRate: 5,
+	RPE:          5,
+	Manual:       5,
+	CustomName:   2,
+	Altitude:     4,
+	LowBattery:   3,
+}
+
+// weightFields maps each weight's config name to its field, in config order
+func (w *Weights) weightFields() []weightField {
+	return []weightField{
+		{"gps", &w.GPS},
+		{"heartrate", &w.HeartRate},
+		{"power", &w.Power},
+		{"cadence", &w.Cadence},
+		{"sampling_rate", &w.SamplingRate},
+		{"rpe", &w.RPE},
+		{"manual", &w.Manual},
+		{"custom_name", &w.CustomName},
+		{"altitude", &w.Altitude},
+		{"low_battery", &w.LowBattery},
+	}
+}
+
+// weightComments explain the less obvious weights in generated configs
+var weightComments = map[string]string{
+	"sampling_rate": "Prioritizes higher frequency (e.g. 1s vs smart recording)",
+	"rpe":           "Bonus for presence of RPE/Feel (user interaction)",
+	"manual":        "Bonus for custom notes/description",
+	"custom_name":   "Bonus for non-generic names",
+	"altitude":      "Bonus for barometric elevation (GPS-derived elevation gets a fraction)",
+	"low_battery":   "Penalty when the power meter battery is low (doubled when critical)",
+}
+
+type weightField struct {
+	Name  string
+	Value *float64
+}
+
+// Apply computes the weighted sum of a scorecard's features
+func (w Weights) Apply(features map[string]float64) float64 {
+	total := 0.0
+	for _, f := range w.weightFields() {
+		total += *f.Value * features[f.Name]
+	}
+	return total
 }
 
 // IntervalsTime handles parsing of ISO-8601 timestamps that may or may not have timezone offsets
@@ -62,14 +138,17 @@ type Activity struct {
 	Name                string        `json:"name"`
 	Type                string        `json:"type"`
 	StartDateLocal      IntervalsTime `json:"start_date_local"`
+	StartDate           IntervalsTime `json:"start_date"`
 	CreatedAt           IntervalsTime `json:"created"`
 	DeviceName          string        `json:"device_name"`
 	Source              string        `json:"source"`
 	IcuRecordingSeconds int           `json:"icu_recording_seconds"`
 	Distance            float64       `json:"distance"`
 	MovingTime          int           `json:"moving_time"`
+	ElapsedTime         int           `json:"elapsed_time"`
 	AverageHeartrate    float64       `json:"average_heartrate"`
 	AverageWatts        float64       `json:"average_power"`
+	NormalizedWatts     float64       `json:"icu_weighted_avg_watts"`
 	HasGPS              bool          `json:"has_gps"` // Derived or checked via streams
 	RPE                 int           `json:"icu_rpe"`
 	Feel                int           `json:"feel"`
@@ -80,17 +159,31 @@ type Activity struct {
 	PowerMeter          string        `json:"power_meter"`
 	PowerMeterSerial    string        `json:"power_meter_serial"`
 	PowerMeterBattery   string        `json:"power_meter_battery"`
+	TotalElevationGain  float64       `json:"total_elevation_gain"`
+	Tags                []string      `json:"tags"`
+	PairedEventID       int           `json:"paired_event_id"`
+	ChatID              int           `json:"icu_chat_id"` // Set once the activity has comments
 }
 
 // ActivityDetail provides more in-depth info used for heuristic evaluation
 type ActivityDetail struct {
 	Activity
-	StreamTypes []string `json:"stream_types"`
+	StreamTypes []string  `json:"stream_types"`
+	Altitude    []float64 `json:"-"` // Altitude stream samples, fetched for duplicate group members
 }
 
 // Scorecard records the breakdown of how an activity was evaluated
 type Scorecard struct {
 	Total      float64
 	Breakdown  map[string]float64
+	Features   map[string]float64 // Unweighted inputs keyed by weight name, used for tuning
 	Reasonings []string
 }
+
+// Stream is a single data channel of an activity (e.g. heartrate, watts).
+// For latlng, Data holds latitudes and Data2 longitudes.
+type Stream struct {
+	Type  string    `json:"type"`
+	Data  []float64 `json:"data"`
+	Data2 []float64 `json:"data2"`
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const notifyTimeout = 30 * time.Second
+
+// Conditions that trigger a notification
+const (
+	NotifyAlways   = "always"   // Every run
+	NotifyDeleted  = "deleted"  // Something was deleted
+	NotifyUpdated  = "updated"  // A name or metadata was adopted
+	NotifyUploaded = "uploaded" // A stitched split recording was uploaded
+	NotifyPlanned  = "planned"  // A dry run planned changes
+	NotifyErrors   = "errors"   // An action failed
+)
+
+var notifyConditions = map[string]bool{
+	NotifyAlways: true, NotifyDeleted: true, NotifyUpdated: true,
+	NotifyUploaded: true, NotifyPlanned: true, NotifyErrors: true,
+}
+
+// defaultNotifyOn notifies about real changes and failures, not quiet runs
+var defaultNotifyOn = []string{NotifyDeleted, NotifyUpdated, NotifyUploaded, NotifyErrors}
+
+// NotifierConfig configures one notification sink
+type NotifierConfig struct {
+	Type string   `yaml:"type"` // webhook, email, ntfy, gotify or command
+	On   []string `yaml:"on"`   // Conditions that trigger it (default: deleted, updated, uploaded, errors)
+
+	URL     string            `yaml:"url"`     // webhook: endpoint; ntfy: topic URL; gotify: server URL
+	Headers map[string]string `yaml:"headers"` // webhook: extra request headers
+	Token   string            `yaml:"token"`   // ntfy access token or gotify application token
+
+	SMTPHost string   `yaml:"smtp_host"`
+	SMTPPort int      `yaml:"smtp_port"` // Default 587
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+
+	Command []string `yaml:"command"` // Program and arguments; the notification JSON is passed on stdin
+}
+
+// Notification is the summary of a run sent to the notifiers
+type Notification struct {
+	Title     string     `json:"title"`
+	Message   string     `json:"message"`
+	AthleteID string     `json:"athlete_id"`
+	Athlete   string     `json:"athlete,omitempty"`
+	Oldest    time.Time  `json:"oldest"`
+	Newest    time.Time  `json:"newest"`
+	DryRun    bool       `json:"dry_run"`
+	Summary   RunSummary `json:"summary"`
+	Deleted   []string   `json:"deleted"`
+	Adopted   []string   `json:"adopted"`
+	Uploaded  []string   `json:"uploaded"`
+	Errors    []string   `json:"errors"`
+}
+
+// NewNotification summarizes a run's groups, deletions, adoptions and errors
+func NewNotification(r *RunReport) *Notification {
+	n := &Notification{
+		AthleteID: r.AthleteID, Athlete: r.Athlete, Oldest: r.Oldest, Newest: r.Newest,
+		DryRun: r.DryRun, Summary: r.Summary(),
+	}
+
+	describe := func(a ActionReport, g *GroupReport) string {
+		desc := a.ActivityID
+		if g != nil {
+			if m := g.Member(a.ActivityID); m != nil {
+				desc = fmt.Sprintf("%s %q (%s)", m.ID, m.Name, m.Device)
+			}
+		}
+		if a.Status == StatusDryRun {
+			desc += " [dry run]"
+		}
+		return desc
+	}
+	collect := func(actions []ActionReport, g *GroupReport) {
+		for _, a := range actions {
+			switch {
+			case a.Status == StatusError:
+				n.Errors = append(n.Errors, fmt.Sprintf("%s %s: %s", a.Action, a.ActivityID, a.Error))
+			case a.Status != StatusOK && a.Status != StatusDryRun:
+			case a.Action == AuditDelete:
+				n.Deleted = append(n.Deleted, describe(a, g))
+			case a.Action == AuditUpdateName || a.Action == AuditUpdateMetadata:
+				n.Adopted = append(n.Adopted, describe(a, g))
+			case a.Action == AuditUpload:
+				n.Uploaded = append(n.Uploaded, describe(a, g))
+			}
+		}
+	}
+	for _, g := range r.Groups {
+		collect(g.Actions, g)
+	}
+	for _, s := range r.Splits {
+		collect(s.Actions, nil)
+	}
+
+	s := n.Summary
+	verb := "deleted"
+	if r.DryRun {
+		verb = "planned"
+	}
+	n.Title = fmt.Sprintf("intervals-deduper: %d groups, %d %s, %d errors for %s", s.Groups, len(n.Deleted), verb, s.Errors, r.Label())
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Athlete %s, %s to %s", r.Label(), r.Oldest.Format("2006-01-02"), r.Newest.Format("2006-01-02"))
+	if r.DryRun {
+		b.WriteString(" (dry run)")
+	}
+	fmt.Fprintf(&b, "\nScanned %d activities: %d duplicate groups, %d split recordings, %d kept due to mismatch, %d protected\n",
+		s.Scanned, s.Groups, s.Splits, s.MismatchSkipped, s.Protected)
+	for _, section := range []struct {
+		heading string
+		lines   []string
+	}{{"Deleted", n.Deleted}, {"Adopted name/metadata", n.Adopted}, {"Uploaded", n.Uploaded}, {"Errors", n.Errors}} {
+		if len(section.lines) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s:\n", section.heading)
+		for _, line := range section.lines {
+			fmt.Fprintf(&b, "- %s\n", line)
+		}
+	}
+	n.Message = b.String()
+	return n
+}
+
+// Matches reports whether the notification meets any of the conditions
+func (n *Notification) Matches(on []string) bool {
+	if len(on) == 0 {
+		on = defaultNotifyOn
+	}
+	s := n.Summary
+	for _, c := range on {
+		switch c {
+		case NotifyAlways:
+			return true
+		case NotifyDeleted:
+			if s.Deleted > 0 {
+				return true
+			}
+		case NotifyUpdated:
+			if s.Updated > 0 {
+				return true
+			}
+		case NotifyUploaded:
+			if s.Uploaded > 0 {
+				return true
+			}
+		case NotifyPlanned:
+			if s.Planned > 0 {
+				return true
+			}
+		case NotifyErrors:
+			if s.Errors > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Notifier sends notifications to one configured sink
+type Notifier struct {
+	config NotifierConfig
+	send   func(n *Notification) error
+}
+
+// NewNotifiers validates the notification config and builds a notifier per sink
+func NewNotifiers(configs []NotifierConfig) ([]*Notifier, error) {
+	var notifiers []*Notifier
+	for i, c := range configs {
+		for _, on := range c.On {
+			if !notifyConditions[on] {
+				return nil, fmt.Errorf("notifications[%d]: unknown condition %q", i, on)
+			}
+		}
+
+		n := &Notifier{config: c}
+		switch c.Type {
+		case "webhook":
+			n.send = n.sendWebhook
+		case "email":
+			if c.SMTPHost == "" || c.From == "" || len(c.To) == 0 {
+				return nil, fmt.Errorf("notifications[%d]: email requires smtp_host, from and to", i)
+			}
+			n.send = n.sendEmail
+		case "ntfy":
+			n.send = n.sendNtfy
+		case "gotify":
+			if c.Token == "" {
+				return nil, fmt.Errorf("notifications[%d]: gotify requires token", i)
+			}
+			n.send = n.sendGotify
+		case "command":
+			if len(c.Command) == 0 {
+				return nil, fmt.Errorf("notifications[%d]: command requires command", i)
+			}
+			n.send = n.runCommand
+		default:
+			return nil, fmt.Errorf("notifications[%d]: unknown type %q (expected webhook, email, ntfy, gotify or command)", i, c.Type)
+		}
+		if c.Type != "email" && c.Type != "command" && c.URL == "" {
+			return nil, fmt.Errorf("notifications[%d]: %s requires url", i, c.Type)
+		}
+		notifiers = append(notifiers, n)
+	}
+	return notifiers, nil
+}
+
+// Notify sends the notification if it meets the sink's conditions
+func (n *Notifier) Notify(notification *Notification) error {
+	if !notification.Matches(n.config.On) {
+		return nil
+	}
+	if err := n.send(notification); err != nil {
+		return fmt.Errorf("%s notification: %w", n.config.Type, err)
+	}
+	return nil
+}
+
+var notifyHTTPClient = &http.Client{Timeout: notifyTimeout}
+
+func (n *Notifier) post(req *http.Request) error {
+	resp, err := notifyHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// sendWebhook posts the notification as JSON
+func (n *Notifier) sendWebhook(notification *Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", n.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range n.config.Headers {
+		req.Header.Set(k, v)
+	}
+	return n.post(req)
+}
+
+// sendNtfy publishes the message to an ntfy topic URL
+func (n *Notifier) sendNtfy(notification *Notification) error {
+	req, err := http.NewRequest("POST", n.config.URL, strings.NewReader(notification.Message))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", encodeHeader(notification.Title))
+	if notification.Summary.Errors > 0 {
+		req.Header.Set("Priority", "high")
+		req.Header.Set("Tags", "warning")
+	}
+	if n.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.config.Token)
+	}
+	return n.post(req)
+}
+
+// sendGotify posts the message to a Gotify server
+func (n *Notifier) sendGotify(notification *Notification) error {
+	priority := 5
+	if notification.Summary.Errors > 0 {
+		priority = 8
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"title":    notification.Title,
+		"message":  notification.Message,
+		"priority": priority,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", strings.TrimSuffix(n.config.URL, "/")+"/message", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", n.config.Token)
+	return n.post(req)
+}
+
+// sendEmail sends the message as a plain-text email, using STARTTLS when the
+// server offers it
+func (n *Notifier) sendEmail(notification *Notification) error {
+	port := n.config.SMTPPort
+	if port == 0 {
+		port = 587
+	}
+	var auth smtp.Auth
+	if n.config.Username != "" {
+		auth = smtp.PlainAuth("", n.config.Username, n.config.Password, n.config.SMTPHost)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.config.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", encodeHeader(notification.Title))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(notification.Message, "\n", "\r\n"))
+
+	addr := fmt.Sprintf("%s:%d", n.config.SMTPHost, port)
+	return smtp.SendMail(addr, auth, n.config.From, n.config.To, msg.Bytes())
+}
+
+// encodeHeader makes text safe for a mail or HTTP header: line breaks (e.g. from
+// an athlete's name) are flattened and non-ASCII text is RFC 2047 encoded
+func encodeHeader(text string) string {
+	text = strings.Join(strings.FieldsFunc(text, func(r rune) bool { return r == '\r' || r == '\n' }), " ")
+	return mime.QEncoding.Encode("utf-8", text)
+}
+
+// runCommand runs a local program with the notification JSON on stdin and the
+// title/message in DEDUPER_TITLE/DEDUPER_MESSAGE
+func (n *Notifier) runCommand(notification *Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, n.config.Command[0], n.config.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(), "DEDUPER_TITLE="+notification.Title, "DEDUPER_MESSAGE="+notification.Message)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// notifyOutput sends each finished run to the notifiers before passing it on
+type notifyOutput struct {
+	OutputWriter
+	notifiers []*Notifier
+	progress  io.Writer // Where delivery failures are reported
+}
+
+func (o notifyOutput) Finish(r *RunReport) {
+	notification := NewNotification(r)
+	for _, n := range o.notifiers {
+		if err := n.Notify(notification); err != nil {
+			fmt.Fprintf(o.progress, "⚠️  %v\n", err)
+		}
+	}
+	o.OutputWriter.Finish(r)
+}
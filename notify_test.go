@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func notifyTestReport() *RunReport {
+	return &RunReport{
+		AthleteID: "i0",
+		Athlete:   "Alice",
+		Scanned:   4,
+		Groups: []*GroupReport{{
+			WinnerID: "i1",
+			Members: []*MemberReport{
+				{ID: "i1", Name: "Hill Repeats", Device: "Garmin Edge 530", Plan: PlanKeep},
+				{ID: "i2", Name: "Morning Ride", Device: "Phone", Plan: PlanDelete},
+			},
+			Actions: []ActionReport{
+				{ActivityID: "i1", Action: AuditUpdateName, Status: StatusOK},
+				{ActivityID: "i2", Action: AuditDelete, Status: StatusOK},
+			},
+		}},
+	}
+}
+
+func TestNewNotification(t *testing.T) {
+	n := NewNotification(notifyTestReport())
+
+	if len(n.Deleted) != 1 || !strings.Contains(n.Deleted[0], `i2 "Morning Ride" (Phone)`) {
+		t.Errorf("deleted = %v", n.Deleted)
+	}
+	if len(n.Adopted) != 1 || len(n.Errors) != 0 {
+		t.Errorf("adopted = %v, errors = %v", n.Adopted, n.Errors)
+	}
+	if !strings.Contains(n.Title, "1 deleted") || !strings.Contains(n.Title, "Alice (i0)") {
+		t.Errorf("title = %q", n.Title)
+	}
+	if !strings.Contains(n.Message, "Deleted:\n- i2") {
+		t.Errorf("message = %q", n.Message)
+	}
+}
+
+func TestNotificationMatches(t *testing.T) {
+	quiet := &Notification{Summary: RunSummary{Scanned: 10}}
+	deleted := &Notification{Summary: RunSummary{Deleted: 1}}
+	failed := &Notification{Summary: RunSummary{Errors: 1}}
+	planned := &Notification{Summary: RunSummary{Planned: 2}}
+
+	tests := []struct {
+		name string
+		n    *Notification
+		on   []string
+		want bool
+	}{
+		{"default quiet", quiet, nil, false},
+		{"default deleted", deleted, nil, true},
+		{"default failed", failed, nil, true},
+		{"default dry run", planned, nil, false},
+		{"planned", planned, []string{NotifyPlanned}, true},
+		{"errors only", deleted, []string{NotifyErrors}, false},
+		{"always", quiet, []string{NotifyAlways}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.n.Matches(tt.on); got != tt.want {
+			t.Errorf("%s: Matches(%v) = %v; want %v", tt.name, tt.on, got, tt.want)
+		}
+	}
+}
+
+func TestNewNotifiersInvalid(t *testing.T) {
+	for _, c := range []NotifierConfig{
+		{Type: "pager"},
+		{Type: "webhook"},
+		{Type: "webhook", URL: "http://x", On: []string{"sometimes"}},
+		{Type: "email", SMTPHost: "smtp.example.com"},
+		{Type: "gotify", URL: "http://x"},
+		{Type: "command"},
+	} {
+		if _, err := NewNotifiers([]NotifierConfig{c}); err == nil {
+			t.Errorf("NewNotifiers(%+v) succeeded; want error", c)
+		}
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var got Notification
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+
+	notifiers, err := NewNotifiers([]NotifierConfig{{Type: "webhook", URL: server.URL, Headers: map[string]string{"Authorization": "Bearer t"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := notifiers[0].Notify(NewNotification(notifyTestReport())); err != nil {
+		t.Fatalf("Notify error: %v", err)
+	}
+	if got.AthleteID != "i0" || got.Summary.Deleted != 1 || auth != "Bearer t" {
+		t.Errorf("received %+v with auth %q", got, auth)
+	}
+}
+
+func TestNtfyNotifier(t *testing.T) {
+	var title string
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		title, _ = new(mime.WordDecoder).DecodeHeader(r.Header.Get("Title"))
+	}))
+	defer server.Close()
+
+	notifiers, err := NewNotifiers([]NotifierConfig{{Type: "ntfy", URL: server.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := notifyTestReport()
+	report.Athlete = "Zoë\r\nX-Injected: 1"
+	if err := notifiers[0].Notify(NewNotification(report)); err != nil {
+		t.Fatalf("Notify error: %v", err)
+	}
+	if !strings.Contains(title, "Zoë X-Injected: 1") || header.Get("X-Injected") != "" {
+		t.Errorf("title = %q, headers %v; want the name on one encoded line", title, header)
+	}
+}
+
+func TestEncodeHeader(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"Deduper: 1 deleted", "Deduper: 1 deleted"},
+		{"Zoë", "=?utf-8?q?Zo=C3=AB?="},
+		{"a\r\nBcc: x@example.com", "a Bcc: x@example.com"},
+	}
+	for _, tt := range tests {
+		if got := encodeHeader(tt.in); got != tt.want {
+			t.Errorf("encodeHeader(%q) = %q; want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCommandNotifier(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	notifiers, err := NewNotifiers([]NotifierConfig{{Type: "command", Command: []string{"sh", "-c", `printf '%s' "$DEDUPER_TITLE" > "$0"`, out}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := NewNotification(notifyTestReport())
+	if err := notifiers[0].Notify(n); err != nil {
+		t.Fatalf("Notify error: %v", err)
+	}
+	data, _ := os.ReadFile(out)
+	if string(data) != n.Title {
+		t.Errorf("command received %q; want %q", data, n.Title)
+	}
+}
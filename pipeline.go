@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// evaluatedActivity pairs an activity's details with its scorecard
+type evaluatedActivity struct {
+	Detail ActivityDetail
+	Score  Scorecard
+}
+
+// groupActivities sorts activities by start time and groups those that start
+// within 30 seconds of each other. Only groups with more than one member are returned.
+func groupActivities(activities []Activity) [][]Activity {
+	// Sort activities by StartDateLocal
+	sort.Slice(activities, func(i, j int) bool {
+		return activities[i].StartDateLocal.Time.Before(activities[j].StartDateLocal.Time)
+	})
+
+	// Group activities that are within 30 seconds of each other
+	var groups [][]Activity
+	if len(activities) > 0 {
+		currentGroup := []Activity{activities[0]}
+		for i := 1; i < len(activities); i++ {
+			diff := activities[i].StartDateLocal.Time.Sub(currentGroup[0].StartDateLocal.Time)
+			if diff < 0 {
+				diff = -diff
+			}
+
+			if diff <= 30*time.Second {
+				currentGroup = append(currentGroup, activities[i])
+			} else {
+				if len(currentGroup) > 1 {
+					groups = append(groups, currentGroup)
+				}
+				currentGroup = []Activity{activities[i]}
+			}
+		}
+		if len(currentGroup) > 1 {
+			groups = append(groups, currentGroup)
+		}
+	}
+
+	return groups
+}
+
+// fetchDetails fetches details for each group member to get stream info, plus
+// the altitude samples used to classify the elevation source. Members that fail
+// to load are reported and left out; a missing altitude stream only weakens the
+// altitude classification.
+func fetchDetails(w io.Writer, client *IntervalsClient, group []Activity) []ActivityDetail {
+	var details []ActivityDetail
+	for _, a := range group {
+		detail, err := client.GetActivityDetail(a.ID)
+		if err != nil {
+			fmt.Fprintf(w, "  ⚠️ Failed to fetch details for %s: %v\n", a.ID, err)
+			continue
+		}
+		if hasStream(detail, "altitude") {
+			if streams, err := client.GetStreams(a.ID, "altitude"); err == nil {
+				for _, st := range streams {
+					if st.Type == "altitude" {
+						detail.Altitude = st.Data
+					}
+				}
+			}
+		}
+		details = append(details, *detail)
+	}
+	return details
+}
+
+// rankDetails scores each activity and sorts them best first.
+func rankDetails(scoring *ScoringEngine, details []ActivityDetail) []evaluatedActivity {
+	var evaluated []evaluatedActivity
+	for _, d := range details {
+		evaluated = append(evaluated, evaluatedActivity{
+			Detail: d,
+			Score:  scoring.Score(&d),
+		})
+	}
+
+	// Sort by Score DESC, then the configured tie-breaker chain
+	sort.SliceStable(evaluated, func(i, j int) bool {
+		c, _ := scoring.Compare(&evaluated[i], &evaluated[j])
+		return c < 0
+	})
+
+	return evaluated
+}
+
+// describeSystem formats the recording device and uploader, e.g. "Wahoo ELEMNT / RunGap".
+func describeSystem(detail *ActivityDetail) string {
+	system := detail.DeviceName
+	src := detail.Source
+	if src == "OAUTH_CLIENT" && detail.OAuthClientName != "" {
+		src = detail.OAuthClientName
+	}
+	if src != "" && src != "OAUTH_CLIENT" && !strings.Contains(strings.ToLower(detail.DeviceName), strings.ToLower(src)) {
+		system = fmt.Sprintf("%s / %s", detail.DeviceName, src)
+	}
+	return system
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// PowerComparison reports how one power source in a duplicate group compares
+// against the reference recording (the best ranked member with power).
+type PowerComparison struct {
+	ID              string
+	Source          string
+	AverageWatts    float64
+	NormalizedWatts float64
+	AverageDiff     float64 // Watts, relative to the reference
+	NormalizedDiff  float64 // Watts, relative to the reference
+	BiasPercent     float64 // Normalized power bias relative to the reference
+}
+
+// PowerSourceLabel describes where an activity's power data came from.
+func PowerSourceLabel(detail *ActivityDetail) string {
+	label := detail.PowerMeter
+	if label == "" {
+		label = detail.DeviceName
+	}
+	if detail.PowerMeterSerial != "" {
+		label = fmt.Sprintf("%s #%s", label, detail.PowerMeterSerial)
+	}
+	if strings.TrimSpace(label) == "" {
+		return "unknown"
+	}
+	return label
+}
+
+// ComparePower compares every member with a power stream against the reference:
+// the first member (in rank order) that recorded power, which need not be the
+// winner. It returns no comparisons when fewer than two members recorded power.
+func ComparePower(members []*ActivityDetail) (*ActivityDetail, []PowerComparison) {
+	var reference *ActivityDetail
+	var others []*ActivityDetail
+	for _, d := range members {
+		if reference == nil && hasStream(d, "watts") {
+			reference = d
+			continue
+		}
+		others = append(others, d)
+	}
+	if reference == nil {
+		return nil, nil
+	}
+
+	refNorm := reference.NormalizedWatts
+	if refNorm == 0 {
+		refNorm = reference.AverageWatts
+	}
+
+	var comparisons []PowerComparison
+	for _, d := range others {
+		if !hasStream(d, "watts") {
+			continue
+		}
+		norm := d.NormalizedWatts
+		if norm == 0 {
+			norm = d.AverageWatts
+		}
+
+		c := PowerComparison{
+			ID:              d.ID,
+			Source:          PowerSourceLabel(d),
+			AverageWatts:    d.AverageWatts,
+			NormalizedWatts: norm,
+			AverageDiff:     d.AverageWatts - reference.AverageWatts,
+			NormalizedDiff:  norm - refNorm,
+		}
+		if refNorm > 0 {
+			c.BiasPercent = (norm - refNorm) / refNorm * 100
+		}
+		comparisons = append(comparisons, c)
+	}
+
+	return reference, comparisons
+}
+
+// printPowerComparison prints the dual-recording comparison for a group.
+func printPowerComparison(w io.Writer, reference *ActivityDetail, comparisons []PowerComparison) {
+	if len(comparisons) == 0 {
+		return
+	}
+
+	refNorm := reference.NormalizedWatts
+	if refNorm == 0 {
+		refNorm = reference.AverageWatts
+	}
+
+	fmt.Fprintf(w, "  ⚡ Power comparison (reference: %s, avg %.0fW, NP %.0fW)\n",
+		PowerSourceLabel(reference), reference.AverageWatts, refNorm)
+	for _, c := range comparisons {
+		warning := ""
+		if math.Abs(c.BiasPercent) > 5 {
+			warning = " ⚠️ [POWER BIAS]"
+		}
+		fmt.Fprintf(w, "    - [%s] (ID: %s) avg %.0fW (%+.0fW), NP %.0fW (%+.0fW), bias %+.1f%%%s\n",
+			c.Source, c.ID, c.AverageWatts, c.AverageDiff, c.NormalizedWatts, c.NormalizedDiff, c.BiasPercent, warning)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestComparePower(t *testing.T) {
+	reference := &ActivityDetail{
+		Activity:    Activity{ID: "i1", PowerMeter: "Assioma", AverageWatts: 200, NormalizedWatts: 220},
+		StreamTypes: []string{"watts"},
+	}
+	trainer := &ActivityDetail{
+		Activity:    Activity{ID: "i2", DeviceName: "KICKR", AverageWatts: 210, NormalizedWatts: 231},
+		StreamTypes: []string{"watts"},
+	}
+	noPower := &ActivityDetail{
+		Activity:    Activity{ID: "i3"},
+		StreamTypes: []string{"heartrate"},
+	}
+
+	ref, got := ComparePower([]*ActivityDetail{reference, trainer, noPower})
+	if ref != reference {
+		t.Errorf("reference = %+v; want the first member with power", ref)
+	}
+	if len(got) != 1 {
+		t.Fatalf("ComparePower returned %d comparisons; want 1", len(got))
+	}
+	c := got[0]
+	if c.ID != "i2" || c.Source != "KICKR" {
+		t.Errorf("unexpected comparison target: %+v", c)
+	}
+	if c.AverageDiff != 10 || c.NormalizedDiff != 11 {
+		t.Errorf("diffs = %.1f/%.1f; want 10/11", c.AverageDiff, c.NormalizedDiff)
+	}
+	if math.Abs(c.BiasPercent-5) > 0.001 {
+		t.Errorf("BiasPercent = %.3f; want 5", c.BiasPercent)
+	}
+
+	// A winner without power still lets the losers' power sources be compared
+	ref, got = ComparePower([]*ActivityDetail{noPower, reference, trainer})
+	if ref != reference || len(got) != 1 || got[0].ID != "i2" || got[0].AverageDiff != 10 {
+		t.Errorf("without winner power: reference %v, comparisons %+v; want i2 against i1", ref, got)
+	}
+
+	if _, got := ComparePower([]*ActivityDetail{noPower, trainer}); len(got) != 0 {
+		t.Errorf("expected no comparison with a single power source, got %v", got)
+	}
+}
+
+func TestPowerMeterPriority(t *testing.T) {
+	s := NewScoringEngine(&Config{PowerMeterPriority: []string{"Assioma", "KICKR"}})
+
+	pedals := &ActivityDetail{
+		Activity:    Activity{PowerMeter: "Assioma Duo", PowerMeterSerial: "12345"},
+		StreamTypes: []string{"watts"},
+	}
+	trainer := &ActivityDetail{
+		Activity:    Activity{DeviceName: "Wahoo KICKR"},
+		StreamTypes: []string{"watts"},
+	}
+
+	if p, tr := s.Score(pedals).Total, s.Score(trainer).Total; p <= tr {
+		t.Errorf("expected trusted pedals (%.2f) to outscore trainer (%.2f)", p, tr)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ProtectConfig lists activities that must never be deleted automatically
+type ProtectConfig struct {
+	IDs           []string         `yaml:"ids"`
+	NamePatterns  []string         `yaml:"name_patterns"` // Regular expressions matched against the name
+	Tags          []string         `yaml:"tags"`
+	Types         []string         `yaml:"types"`
+	PairedWorkout bool             `yaml:"paired_workout"` // Activities matched to a planned workout
+	HasComments   bool             `yaml:"has_comments"`
+	DateRanges    []ProtectedRange `yaml:"date_ranges"`
+}
+
+// ProtectedRange is an inclusive range of dates (YYYY-MM-DD)
+type ProtectedRange struct {
+	Start string `yaml:"start"`
+	End   string `yaml:"end"`
+}
+
+// Protector decides whether an activity is protected from deletion
+type Protector struct {
+	config   ProtectConfig
+	patterns []*regexp.Regexp
+	ranges   [][2]time.Time
+}
+
+func NewProtector(config ProtectConfig) (*Protector, error) {
+	p := &Protector{config: config}
+
+	for _, pattern := range config.NamePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid protect name pattern %q: %w", pattern, err)
+		}
+		p.patterns = append(p.patterns, re)
+	}
+
+	for _, r := range config.DateRanges {
+		start, err := time.Parse("2006-01-02", r.Start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid protect date range start %q: %w", r.Start, err)
+		}
+		end := start
+		if r.End != "" {
+			end, err = time.Parse("2006-01-02", r.End)
+			if err != nil {
+				return nil, fmt.Errorf("invalid protect date range end %q: %w", r.End, err)
+			}
+		}
+		// Include the full end day
+		p.ranges = append(p.ranges, [2]time.Time{start, end.AddDate(0, 0, 1)})
+	}
+
+	return p, nil
+}
+
+// Check returns the reason an activity is protected, or "" when it is not
+func (p *Protector) Check(a *Activity) string {
+	for _, id := range p.config.IDs {
+		if a.ID == id {
+			return "listed ID"
+		}
+	}
+	for _, re := range p.patterns {
+		if re.MatchString(a.Name) {
+			return fmt.Sprintf("name matches %q", re.String())
+		}
+	}
+	for _, tag := range p.config.Tags {
+		for _, t := range a.Tags {
+			if strings.EqualFold(t, tag) {
+				return fmt.Sprintf("tagged %q", t)
+			}
+		}
+	}
+	for _, t := range p.config.Types {
+		if strings.EqualFold(a.Type, t) {
+			return fmt.Sprintf("type %s", a.Type)
+		}
+	}
+	if p.config.PairedWorkout && a.PairedEventID != 0 {
+		return "paired with a planned workout"
+	}
+	if p.config.HasComments && a.ChatID != 0 {
+		return "has comments"
+	}
+	day := a.StartDateLocal.Time
+	// Compare on the local calendar date regardless of the parsed location
+	date := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	for _, r := range p.ranges {
+		if !date.Before(r[0]) && date.Before(r[1]) {
+			return fmt.Sprintf("within protected dates %s to %s", r[0].Format("2006-01-02"), r[1].AddDate(0, 0, -1).Format("2006-01-02"))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProtectorCheck(t *testing.T) {
+	p, err := NewProtector(ProtectConfig{
+		IDs:           []string{"i1"},
+		NamePatterns:  []string{`(?i)\bftp\b`},
+		Tags:          []string{"race"},
+		Types:         []string{"Race"},
+		PairedWorkout: true,
+		HasComments:   true,
+		DateRanges:    []ProtectedRange{{Start: "2024-06-01", End: "2024-06-02"}},
+	})
+	if err != nil {
+		t.Fatalf("NewProtector error: %v", err)
+	}
+
+	day := func(d int) IntervalsTime {
+		return IntervalsTime{time.Date(2024, 5, d, 18, 0, 0, 0, time.UTC)}
+	}
+
+	tests := []struct {
+		activity  Activity
+		protected bool
+	}{
+		{Activity{ID: "i1"}, true},
+		{Activity{ID: "i2", Name: "Morning FTP Test"}, true},
+		{Activity{ID: "i3", Tags: []string{"Race"}}, true},
+		{Activity{ID: "i4", Type: "race"}, true},
+		{Activity{ID: "i5", PairedEventID: 42}, true},
+		{Activity{ID: "i6", ChatID: 7}, true},
+		{Activity{ID: "i7", StartDateLocal: IntervalsTime{time.Date(2024, 6, 2, 23, 0, 0, 0, time.UTC)}}, true},
+		{Activity{ID: "i8", Name: "Afternoon Ride", Type: "Ride", StartDateLocal: day(31)}, false},
+	}
+
+	for _, tt := range tests {
+		reason := p.Check(&tt.activity)
+		if (reason != "") != tt.protected {
+			t.Errorf("Check(%s) = %q; want protected=%v", tt.activity.ID, reason, tt.protected)
+		}
+	}
+}
+
+func TestNewProtectorInvalid(t *testing.T) {
+	if _, err := NewProtector(ProtectConfig{NamePatterns: []string{"("}}); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+	if _, err := NewProtector(ProtectConfig{DateRanges: []ProtectedRange{{Start: "June"}}}); err == nil {
+		t.Error("expected error for invalid date")
+	}
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Planned actions for group members
+const (
+	PlanKeep      = "keep"      // Winner
+	PlanDelete    = "delete"    // Loser to be deleted
+	PlanMismatch  = "mismatch"  // Loser kept due to size difference
+	PlanAdoptOnly = "adopt"     // Loser kept, metadata adopted
+	PlanSplit     = "split"     // Loser kept as a split recording candidate
+	PlanFlagged   = "flagged"   // Loser kept and flagged for review due to size difference
+	PlanProtected = "protected" // Loser matched a protect rule
+)
+
+// Action statuses
+const (
+	StatusOK       = "ok"
+	StatusError    = "error"
+	StatusDryRun   = "dry_run"
+	StatusDeclined = "declined"
+)
+
+// RunReport is the structured outcome of a de-dup run for one athlete
+type RunReport struct {
+	AthleteID string         `json:"athlete_id"`
+	Athlete   string         `json:"athlete,omitempty"` // Configured athlete name
+	Oldest    time.Time      `json:"oldest"`
+	Newest    time.Time      `json:"newest"`
+	DryRun    bool           `json:"dry_run"`
+	Scanned   int            `json:"scanned"`
+	Groups    []*GroupReport `json:"groups"`
+	Splits    []*SplitReport `json:"splits"`
+}
+
+// GroupReport describes one duplicate group and what happened to it
+type GroupReport struct {
+	AthleteID string            `json:"athlete_id"`
+	ID        string            `json:"id"` // Sorted member IDs
+	Start     time.Time         `json:"start"`
+	Ignored   bool              `json:"ignored,omitempty"`
+	WinnerID  string            `json:"winner_id,omitempty"`
+	DecidedBy string            `json:"decided_by,omitempty"` // "score", a tie-breaker or "manual"
+	Members   []*MemberReport   `json:"members"`
+	Power     []PowerComparison `json:"power_comparison,omitempty"`
+	PowerRef  string            `json:"power_reference,omitempty"` // Member the power comparison is relative to
+	Actions   []ActionReport    `json:"actions"`
+}
+
+// MemberReport is one scored member of a duplicate group
+type MemberReport struct {
+	ID           string             `json:"id"`
+	Name         string             `json:"name"`
+	Type         string             `json:"type"`
+	Device       string             `json:"device"`
+	Uploader     string             `json:"uploader"`
+	Distance     float64            `json:"distance"`
+	MovingTime   int                `json:"moving_time"`
+	Rank         int                `json:"rank"`
+	Score        float64            `json:"score"`
+	Breakdown    map[string]float64 `json:"breakdown"`
+	Reasonings   []string           `json:"reasonings"`
+	DistDiff     float64            `json:"dist_diff"` // Fraction of the winner's distance
+	TimeDiff     float64            `json:"time_diff"` // Fraction of the winner's moving time
+	DistMismatch bool               `json:"dist_mismatch,omitempty"`
+	TimeMismatch bool               `json:"time_mismatch,omitempty"`
+	Protected    string             `json:"protected,omitempty"` // Protect rule reason
+	Plan         string             `json:"plan"`
+}
+
+// ActionReport is a mutation that was attempted, simulated or declined
+type ActionReport struct {
+	ActivityID string                 `json:"activity_id"`
+	Action     string                 `json:"action"` // Audit action name
+	Changes    map[string]interface{} `json:"changes,omitempty"`
+	Status     string                 `json:"status"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// SplitReport describes a split recording and what happened to its fragments
+type SplitReport struct {
+	AthleteID  string         `json:"athlete_id"`
+	Start      time.Time      `json:"start"`
+	Fragments  []string       `json:"fragments"`
+	CompleteID string         `json:"complete_id,omitempty"`
+	Actions    []ActionReport `json:"actions"`
+}
+
+// RunSummary counts the outcome of a run
+type RunSummary struct {
+	Scanned         int `json:"scanned"`
+	Groups          int `json:"groups"`
+	Splits          int `json:"splits"`
+	Deleted         int `json:"deleted"`
+	Updated         int `json:"updated"`
+	Uploaded        int `json:"uploaded"`
+	Planned         int `json:"planned"` // Dry-run actions
+	Declined        int `json:"declined"`
+	Errors          int `json:"errors"`
+	MismatchSkipped int `json:"mismatch_skipped"`
+	Protected       int `json:"protected"`
+}
+
+// Add sums two summaries, e.g. across athletes
+func (s RunSummary) Add(o RunSummary) RunSummary {
+	return RunSummary{
+		Scanned:         s.Scanned + o.Scanned,
+		Groups:          s.Groups + o.Groups,
+		Splits:          s.Splits + o.Splits,
+		Deleted:         s.Deleted + o.Deleted,
+		Updated:         s.Updated + o.Updated,
+		Uploaded:        s.Uploaded + o.Uploaded,
+		Planned:         s.Planned + o.Planned,
+		Declined:        s.Declined + o.Declined,
+		Errors:          s.Errors + o.Errors,
+		MismatchSkipped: s.MismatchSkipped + o.MismatchSkipped,
+		Protected:       s.Protected + o.Protected,
+	}
+}
+
+// Label names the report's athlete, preferring the configured name
+func (r *RunReport) Label() string {
+	if r.Athlete != "" {
+		return fmt.Sprintf("%s (%s)", r.Athlete, r.AthleteID)
+	}
+	return r.AthleteID
+}
+
+// newActionReport derives the status of an action from the dry-run flag and error
+func newActionReport(activityID, action string, changes map[string]interface{}, dryRun bool, err error) ActionReport {
+	a := ActionReport{ActivityID: activityID, Action: action, Changes: changes, Status: StatusOK}
+	switch {
+	case dryRun:
+		a.Status = StatusDryRun
+	case err != nil:
+		a.Status = StatusError
+		a.Error = err.Error()
+	}
+	return a
+}
+
+// Member finds a group member by ID
+func (g *GroupReport) Member(id string) *MemberReport {
+	for _, m := range g.Members {
+		if m.ID == id {
+			return m
+		}
+	}
+	return nil
+}
+
+// Summary counts the groups, actions and outcomes in the report
+func (r *RunReport) Summary() RunSummary {
+	s := RunSummary{Scanned: r.Scanned, Splits: len(r.Splits)}
+
+	var actions []ActionReport
+	for _, g := range r.Groups {
+		if g.Ignored {
+			continue
+		}
+		s.Groups++
+		actions = append(actions, g.Actions...)
+		for _, m := range g.Members {
+			switch m.Plan {
+			case PlanMismatch, PlanAdoptOnly, PlanSplit, PlanFlagged:
+				s.MismatchSkipped++
+			case PlanProtected:
+				s.Protected++
+			}
+		}
+	}
+	for _, sp := range r.Splits {
+		actions = append(actions, sp.Actions...)
+	}
+
+	for _, a := range actions {
+		switch a.Status {
+		case StatusDryRun:
+			s.Planned++
+		case StatusDeclined:
+			s.Declined++
+		case StatusError:
+			s.Errors++
+		case StatusOK:
+			switch a.Action {
+			case AuditDelete:
+				s.Deleted++
+			case AuditUpdateName, AuditUpdateMetadata:
+				s.Updated++
+			case AuditUpload:
+				s.Uploaded++
+			}
+		}
+	}
+	return s
+}
+
+// OutputWriter receives structured records as runs progress. Writers are safe
+// for concurrent use by several athletes' runs.
+type OutputWriter interface {
+	Group(g *GroupReport)
+	Split(s *SplitReport)
+	Finish(r *RunReport)
+	// Flush writes anything buffered since the last flush, e.g. once every
+	// athlete has been processed
+	Flush()
+}
+
+// NewOutputWriter returns the writer for an --output format
+func NewOutputWriter(format string, w io.Writer) (OutputWriter, error) {
+	switch format {
+	case "", "human":
+		return &humanOutput{w: w}, nil
+	case "json":
+		return &jsonOutput{w: w}, nil
+	case "jsonl":
+		return &jsonlOutput{enc: json.NewEncoder(w)}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q (expected human, json or jsonl)", format)
+	}
+}
+
+// reportBuffer collects finished run reports until they are flushed
+type reportBuffer struct {
+	mu      sync.Mutex
+	reports []*RunReport
+}
+
+func (b *reportBuffer) add(r *RunReport) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.reports = append(b.reports, r)
+}
+
+func (b *reportBuffer) take() []*RunReport {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	reports := b.reports
+	b.reports = nil
+	return reports
+}
+
+// humanOutput prints groups as the run progresses, so it only adds a
+// per-athlete summary when several athletes were processed
+type humanOutput struct {
+	reportBuffer
+	w io.Writer
+}
+
+func (*humanOutput) Group(*GroupReport) {}
+func (*humanOutput) Split(*SplitReport) {}
+
+func (o *humanOutput) Finish(r *RunReport) { o.add(r) }
+
+func (o *humanOutput) Flush() {
+	reports := o.take()
+	if len(reports) < 2 {
+		return
+	}
+	tw := tabwriter.NewWriter(o.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "\n📋 Athlete\tScanned\tGroups\tDeleted\tUpdated\tPlanned\tErrors\n")
+	for _, r := range reports {
+		s := r.Summary()
+		fmt.Fprintf(tw, "   %s\t%d\t%d\t%d\t%d\t%d\t%d\n", r.Label(), s.Scanned, s.Groups, s.Deleted, s.Updated, s.Planned, s.Errors)
+	}
+	tw.Flush()
+}
+
+// jsonOutput writes one document per flush: the run report for a single
+// athlete, or a section per athlete plus the combined summary
+type jsonOutput struct {
+	reportBuffer
+	w io.Writer
+}
+
+func (*jsonOutput) Group(*GroupReport) {}
+func (*jsonOutput) Split(*SplitReport) {}
+
+func (o *jsonOutput) Finish(r *RunReport) { o.add(r) }
+
+// runDocument is a run report with its summary
+type runDocument struct {
+	*RunReport
+	Summary RunSummary `json:"summary"`
+}
+
+func (o *jsonOutput) Flush() {
+	reports := o.take()
+	if len(reports) == 0 {
+		return
+	}
+
+	enc := json.NewEncoder(o.w)
+	enc.SetIndent("", "  ")
+	if len(reports) == 1 {
+		enc.Encode(runDocument{reports[0], reports[0].Summary()})
+		return
+	}
+
+	doc := struct {
+		Athletes []runDocument `json:"athletes"`
+		Summary  RunSummary    `json:"summary"`
+	}{}
+	for _, r := range reports {
+		s := r.Summary()
+		doc.Athletes = append(doc.Athletes, runDocument{r, s})
+		doc.Summary = doc.Summary.Add(s)
+	}
+	enc.Encode(doc)
+}
+
+// jsonlOutput streams one record per group/split, then a summary record per athlete
+type jsonlOutput struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (o *jsonlOutput) Group(g *GroupReport) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.enc.Encode(struct {
+		Type string `json:"type"`
+		*GroupReport
+	}{"group", g})
+}
+
+func (o *jsonlOutput) Split(s *SplitReport) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.enc.Encode(struct {
+		Type string `json:"type"`
+		*SplitReport
+	}{"split", s})
+}
+
+func (o *jsonlOutput) Finish(r *RunReport) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.enc.Encode(struct {
+		Type      string    `json:"type"`
+		AthleteID string    `json:"athlete_id"`
+		Oldest    time.Time `json:"oldest"`
+		Newest    time.Time `json:"newest"`
+		DryRun    bool      `json:"dry_run"`
+		RunSummary
+	}{"summary", r.AthleteID, r.Oldest, r.Newest, r.DryRun, r.Summary()})
+}
+
+func (*jsonlOutput) Flush() {}
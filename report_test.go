@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newFakeIntervals serves a two-member duplicate group and records mutations,
+// e.g. "DELETE i2" or `PUT i2 {"name":"Hill Repeats"}`
+func newFakeIntervals(t *testing.T, calls *[]string) *httptest.Server {
+	t.Helper()
+
+	details := map[string]string{
+		"i1": `{"id":"i1","name":"Ellisville - Weldon","type":"Ride","start_date_local":"2024-06-01T07:00:00","device_name":"Wahoo ELEMNT","distance":40000,"moving_time":5400,"stream_types":["latlng","watts","heartrate"]}`,
+		"i2": `{"id":"i2","name":"Morning Ride","type":"Ride","start_date_local":"2024-06-01T07:00:10","device_name":"Phone","distance":39800,"moving_time":5380,"stream_types":["latlng"]}`,
+	}
+
+	deleted := make(map[string]bool)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/athlete/{athlete}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"` + r.PathValue("athlete") + `","name":"Test Athlete"}`))
+	})
+	mux.HandleFunc("GET /api/v1/athlete/{athlete}/activities", func(w http.ResponseWriter, r *http.Request) {
+		var listed []string
+		for _, id := range []string{"i1", "i2"} {
+			if !deleted[id] {
+				listed = append(listed, details[id])
+			}
+		}
+		w.Write([]byte("[" + strings.Join(listed, ",") + "]"))
+	})
+	mux.HandleFunc("GET /api/v1/activity/{id}", func(w http.ResponseWriter, r *http.Request) {
+		d, ok := details[r.PathValue("id")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(d))
+	})
+	mux.HandleFunc("DELETE /api/v1/activity/{id}", func(w http.ResponseWriter, r *http.Request) {
+		deleted[r.PathValue("id")] = true
+		*calls = append(*calls, "DELETE "+r.PathValue("id"))
+	})
+	mux.HandleFunc("PUT /api/v1/activity/{id}", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*calls = append(*calls, "PUT "+r.PathValue("id")+" "+string(body))
+	})
+	return httptest.NewServer(mux)
+}
+
+func newTestRun(t *testing.T, server *httptest.Server, dryRun bool) *Run {
+	t.Helper()
+	dir := t.TempDir()
+	config := &Config{
+		AthleteID:     "i0",
+		Weights:       Weights{GPS: 10, Power: 10, HeartRate: 5},
+		AuditPath:     filepath.Join(dir, "audit.jsonl"),
+		IgnorePath:    filepath.Join(dir, "ignore.json"),
+		DecisionsPath: filepath.Join(dir, "decisions.jsonl"),
+		HistoryPath:   filepath.Join(dir, "history.jsonl"),
+	}
+	client := NewIntervalsClient("key", config.AthleteID)
+	client.BaseURL = server.URL
+
+	run, err := NewRun(config, client, dryRun, false)
+	if err != nil {
+		t.Fatalf("NewRun error: %v", err)
+	}
+	return run
+}
+
+func TestRunJSONLOutput(t *testing.T) {
+	var calls []string
+	server := newFakeIntervals(t, &calls)
+	defer server.Close()
+
+	run := newTestRun(t, server, false)
+	var buf bytes.Buffer
+	writer, err := NewOutputWriter("jsonl", &buf)
+	if err != nil {
+		t.Fatalf("NewOutputWriter error: %v", err)
+	}
+	run.Output = writer
+	var progress bytes.Buffer
+	run.Progress = &progress
+
+	activities, err := run.Client.ListActivities(run.Report.Oldest, run.Report.Newest)
+	if err != nil {
+		t.Fatalf("ListActivities error: %v", err)
+	}
+	run.Process(activities)
+
+	if len(calls) != 1 || calls[0] != "DELETE i2" {
+		t.Errorf("calls = %v; want [DELETE i2]", calls)
+	}
+	// Progress goes to its own writer, leaving the JSONL stream clean
+	if !strings.Contains(progress.String(), "Deleted i2") {
+		t.Errorf("progress = %q; want the deletion reported", progress.String())
+	}
+
+	var types []string
+	var group GroupReport
+	var summary RunSummary
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var rec struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		types = append(types, rec.Type)
+		switch rec.Type {
+		case "group":
+			json.Unmarshal(scanner.Bytes(), &group)
+		case "summary":
+			json.Unmarshal(scanner.Bytes(), &summary)
+		}
+	}
+
+	if strings.Join(types, ",") != "group,summary" {
+		t.Fatalf("record types = %v; want [group summary]", types)
+	}
+	if group.WinnerID != "i1" || len(group.Members) != 2 || group.Members[1].Plan != PlanDelete {
+		t.Errorf("unexpected group record: %+v", group)
+	}
+	if len(group.Actions) != 1 || group.Actions[0].Status != StatusOK {
+		t.Errorf("unexpected actions: %+v", group.Actions)
+	}
+	if summary.Scanned != 2 || summary.Groups != 1 || summary.Deleted != 1 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+}
+
+func TestNewOutputWriterUnknown(t *testing.T) {
+	if _, err := NewOutputWriter("xml", &bytes.Buffer{}); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestJSONOutputAthletes(t *testing.T) {
+	var buf bytes.Buffer
+	writer, _ := NewOutputWriter("json", &buf)
+	writer.Finish(&RunReport{AthleteID: "i1", Scanned: 3})
+	writer.Finish(&RunReport{AthleteID: "i2", Scanned: 4})
+	writer.Flush()
+
+	var doc struct {
+		Athletes []struct {
+			AthleteID string     `json:"athlete_id"`
+			Summary   RunSummary `json:"summary"`
+		} `json:"athletes"`
+		Summary RunSummary `json:"summary"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(doc.Athletes) != 2 || doc.Athletes[1].AthleteID != "i2" || doc.Athletes[1].Summary.Scanned != 4 {
+		t.Errorf("unexpected athletes: %+v", doc.Athletes)
+	}
+	if doc.Summary.Scanned != 7 {
+		t.Errorf("summary.scanned = %d; want 7", doc.Summary.Scanned)
+	}
+
+	buf.Reset()
+	writer.Flush()
+	if buf.Len() != 0 {
+		t.Errorf("second flush wrote %q; want nothing", buf.String())
+	}
+}
+
+func TestRunInteractiveWinnerOverride(t *testing.T) {
+	var calls []string
+	server := newFakeIntervals(t, &calls)
+	defer server.Close()
+
+	run := newTestRun(t, server, false)
+	run.Interactive = true
+
+	// Keep the phone recording, accept the adopted name and confirm the deletion
+	saved := stdin
+	stdin = bufio.NewReader(strings.NewReader("2\n\ny\n"))
+	defer func() { stdin = saved }()
+
+	activities, err := run.Client.ListActivities(run.Report.Oldest, run.Report.Newest)
+	if err != nil {
+		t.Fatalf("ListActivities error: %v", err)
+	}
+	run.Process(activities)
+
+	want := []string{`PUT i2 {"name":"Ellisville - Weldon"}`, "DELETE i1"}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("calls = %v; want %v", calls, want)
+	}
+	group := run.Report.Groups[0]
+	if group.WinnerID != "i2" || group.DecidedBy != "manual" {
+		t.Errorf("WinnerID = %s, DecidedBy = %s; want i2, manual", group.WinnerID, group.DecidedBy)
+	}
+
+	records, err := LoadDecisions(run.Config.DecisionsFile())
+	if err != nil {
+		t.Fatalf("LoadDecisions error: %v", err)
+	}
+	if len(records) == 0 || records[0].Kind != "winner" || records[0].WinnerID != "i1" || records[0].LoserID != "i2" || records[0].Accepted {
+		t.Errorf("unexpected first decision: %+v", records)
+	}
+}
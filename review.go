@@ -0,0 +1,306 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// adoptableFields are the winner fields that can be filled in from other members,
+// keyed by their API name
+var adoptableFields = []string{"name", "feel", "icu_rpe", "description"}
+
+// fieldValue returns an activity's value for an adoptable field and whether it is set
+func fieldValue(d *ActivityDetail, field string) (interface{}, bool) {
+	switch field {
+	case "name":
+		return d.Name, d.Name != ""
+	case "feel":
+		return d.Feel, d.Feel > 0
+	case "icu_rpe":
+		return d.RPE, d.RPE > 0
+	case "description":
+		desc := strings.TrimSpace(d.Description)
+		return desc, desc != ""
+	}
+	return nil, false
+}
+
+// Adoption lists the fields a winner takes over from the other group members
+type Adoption struct {
+	Updates map[string]interface{} // API field -> value
+	Sources map[string]string      // API field -> donor activity ID
+}
+
+// Metadata returns the adopted fields other than the name
+func (a Adoption) Metadata() map[string]interface{} {
+	meta := make(map[string]interface{})
+	for k, v := range a.Updates {
+		if k != "name" {
+			meta[k] = v
+		}
+	}
+	return meta
+}
+
+// metadataReasons describes adopted metadata for prompts, e.g. "Feel: 2, Description"
+func metadataReasons(meta map[string]interface{}) string {
+	var reasons []string
+	if v, ok := meta["feel"]; ok {
+		reasons = append(reasons, fmt.Sprintf("Feel: %v", v))
+	}
+	if v, ok := meta["icu_rpe"]; ok {
+		reasons = append(reasons, fmt.Sprintf("RPE: %v", v))
+	}
+	if _, ok := meta["description"]; ok {
+		reasons = append(reasons, "Description")
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// PlanAdoption picks a descriptive name when the winner's is generic, and fills
+// the winner's missing Feel, RPE and description from the first donor that has them.
+func (s *ScoringEngine) PlanAdoption(winner *ActivityDetail, donors []evaluatedActivity) Adoption {
+	adoption := Adoption{Updates: make(map[string]interface{}), Sources: make(map[string]string)}
+
+	if s.IsGenericName(winner.Name, winner.Type) {
+		var candidateNames []string
+		for _, d := range donors {
+			candidateNames = append(candidateNames, d.Detail.Name)
+		}
+		if best := s.RankCandidateNames(candidateNames, winner.Type); best != "" {
+			adoption.Updates["name"] = best
+			for _, d := range donors {
+				if d.Detail.Name == best {
+					adoption.Sources["name"] = d.Detail.ID
+					break
+				}
+			}
+		}
+	}
+
+	for _, field := range adoptableFields[1:] {
+		if _, set := fieldValue(winner, field); set {
+			continue
+		}
+		for _, d := range donors {
+			if v, ok := fieldValue(&d.Detail, field); ok {
+				adoption.Updates[field] = v
+				adoption.Sources[field] = d.Detail.ID
+				break
+			}
+		}
+	}
+	return adoption
+}
+
+// assessLosers checks each loser for a size mismatch against the winner and
+// returns the losers allowed to donate their name/metadata under the policy
+func assessLosers(rule MismatchRule, winner *ActivityDetail, losers []evaluatedActivity) ([]MismatchResult, []evaluatedActivity) {
+	mismatches := make([]MismatchResult, len(losers))
+	var donors []evaluatedActivity
+	for i, loser := range losers {
+		mismatches[i] = CheckMismatch(rule, winner, &loser.Detail)
+		if !mismatches[i].IsMismatch() || rule.Policy == MismatchAdopt {
+			donors = append(donors, loser)
+		}
+	}
+	return mismatches, donors
+}
+
+// ReviewGroup is a scored duplicate group awaiting a reviewed decision
+type ReviewGroup struct {
+	Key     string
+	Start   time.Time
+	Members []evaluatedActivity // Ranked best first
+	Applied bool
+	Actions []ActionReport
+}
+
+// Member finds a group member by ID
+func (g *ReviewGroup) Member(id string) *evaluatedActivity {
+	for i := range g.Members {
+		if g.Members[i].Detail.ID == id {
+			return &g.Members[i]
+		}
+	}
+	return nil
+}
+
+// split returns the chosen winner and the remaining members in rank order,
+// falling back to the ranked winner for an unknown ID
+func (g *ReviewGroup) split(winnerID string) (evaluatedActivity, []evaluatedActivity) {
+	idx := 0
+	for i, m := range g.Members {
+		if m.Detail.ID == winnerID {
+			idx = i
+		}
+	}
+	var losers []evaluatedActivity
+	for i, m := range g.Members {
+		if i != idx {
+			losers = append(losers, m)
+		}
+	}
+	return g.Members[idx], losers
+}
+
+// Decision is the reviewed outcome for a duplicate group
+type Decision struct {
+	WinnerID string
+	Updates  map[string]interface{} // Fields to set on the winner
+	Sources  map[string]string      // Updated field -> member the value came from
+	Delete   map[string]bool        // Losers to delete
+}
+
+// ReviewGroups scores every duplicate group that isn't ignored, without
+// changing anything
+func (r *Run) ReviewGroups(activities []Activity) []*ReviewGroup {
+	var groups []*ReviewGroup
+	for _, group := range groupActivities(activities) {
+		ids := activityIDs(group)
+		if r.Ignored.Contains(ids) {
+			continue
+		}
+		details := fetchDetails(r.Progress, r.Client, group)
+		if len(details) <= 1 {
+			continue
+		}
+		groups = append(groups, &ReviewGroup{
+			Key:     groupKey(ids),
+			Start:   group[0].StartDateLocal.Time,
+			Members: rankDetails(r.Scoring, details),
+		})
+	}
+	return groups
+}
+
+// ProposeDecision applies the usual adoption, mismatch and protect rules with
+// the given member as the winner
+func (r *Run) ProposeDecision(g *ReviewGroup, winnerID string) Decision {
+	winner, losers := g.split(winnerID)
+	rule := r.Config.MismatchRuleFor(winner.Detail.Type)
+	mismatches, donors := assessLosers(rule, &winner.Detail, losers)
+	adoption := r.Scoring.PlanAdoption(&winner.Detail, donors)
+
+	d := Decision{
+		WinnerID: winner.Detail.ID,
+		Updates:  adoption.Updates,
+		Sources:  adoption.Sources,
+		Delete:   make(map[string]bool),
+	}
+	for i, loser := range losers {
+		if mismatches[i].IsMismatch() {
+			continue
+		}
+		if r.Protector.Check(&loser.Detail.Activity) != "" {
+			continue
+		}
+		d.Delete[loser.Detail.ID] = true
+	}
+	return d
+}
+
+// DescribeDecision reports the group's members as they would be handled by the
+// decision, with size differences measured against the chosen winner
+func (r *Run) DescribeDecision(g *ReviewGroup, d Decision) *GroupReport {
+	winner, _ := g.split(d.WinnerID)
+	rule := r.Config.MismatchRuleFor(winner.Detail.Type)
+
+	report := &GroupReport{ID: g.Key, Start: g.Start, WinnerID: winner.Detail.ID, Actions: g.Actions}
+	for i, e := range g.Members {
+		member := newMemberReport(i+1, e)
+		member.Protected = r.Protector.Check(&e.Detail.Activity)
+		switch {
+		case e.Detail.ID == winner.Detail.ID:
+			member.Plan = PlanKeep
+		default:
+			m := CheckMismatch(rule, &winner.Detail, &e.Detail)
+			member.DistDiff, member.TimeDiff = m.DistDiff, m.TimeDiff
+			member.DistMismatch, member.TimeMismatch = m.DistMismatch, m.TimeMismatch
+			switch {
+			case d.Delete[e.Detail.ID]:
+				member.Plan = PlanDelete
+			case member.Protected != "":
+				member.Plan = PlanProtected
+			case m.IsMismatch():
+				member.Plan = rule.Policy.Plan()
+			default:
+				member.Plan = PlanKeep
+			}
+		}
+		report.Members = append(report.Members, member)
+	}
+	return report
+}
+
+// ApplyDecision updates the winner and deletes the chosen losers, recording each
+// mutation in the audit log. Protected activities and the winner are never deleted.
+func (r *Run) ApplyDecision(g *ReviewGroup, d Decision) []ActionReport {
+	winner := g.Member(d.WinnerID)
+	if winner == nil {
+		return []ActionReport{{ActivityID: d.WinnerID, Action: AuditUpdateMetadata, Status: StatusError, Error: "winner is not a member of the group"}}
+	}
+
+	var actions []ActionReport
+	update := func(action string, updates map[string]interface{}) {
+		if len(updates) == 0 {
+			return
+		}
+		before := make(map[string]interface{})
+		for k := range updates {
+			before[k], _ = fieldValue(&winner.Detail, k)
+		}
+		entry := AuditEntry{
+			ActivityID: winner.Detail.ID,
+			Action:     action,
+			Before:     before,
+			After:      updates,
+			WinnerID:   winner.Detail.ID,
+			Scorecards: auditScorecards(g.Members),
+			DryRun:     r.DryRun,
+		}
+		var err error
+		if !r.DryRun {
+			err = r.Client.UpdateActivity(winner.Detail.ID, updates)
+		}
+		r.recordAction(&actions, entry, err)
+	}
+
+	adoption := Adoption{Updates: d.Updates}
+	if name, ok := d.Updates["name"]; ok {
+		update(AuditUpdateName, map[string]interface{}{"name": name})
+	}
+	update(AuditUpdateMetadata, adoption.Metadata())
+
+	for _, loser := range g.Members {
+		id := loser.Detail.ID
+		if !d.Delete[id] || id == winner.Detail.ID {
+			continue
+		}
+		if reason := r.Protector.Check(&loser.Detail.Activity); reason != "" {
+			actions = append(actions, ActionReport{ActivityID: id, Action: AuditDelete, Status: StatusError, Error: "protected: " + reason})
+			continue
+		}
+		entry := AuditEntry{
+			ActivityID: id,
+			Action:     AuditDelete,
+			Before:     loser.Detail,
+			WinnerID:   winner.Detail.ID,
+			Scorecards: auditScorecards(g.Members),
+			DryRun:     r.DryRun,
+		}
+		var err error
+		if !r.DryRun {
+			err = r.Client.DeleteActivity(id)
+			if err == nil {
+				r.Deleted[id] = true
+			}
+		}
+		r.recordAction(&actions, entry, err)
+	}
+
+	g.Applied = true
+	g.Actions = actions
+	return actions
+}
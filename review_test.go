@@ -0,0 +1,67 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlanAdoption(t *testing.T) {
+	engine := NewScoringEngine(&Config{})
+	winner := &ActivityDetail{Activity: Activity{ID: "i1", Name: "Morning Ride", Type: "Ride", RPE: 6}}
+	donors := []evaluatedActivity{
+		{Detail: ActivityDetail{Activity: Activity{ID: "i2", Name: "Afternoon Ride", Feel: 2, RPE: 4}}},
+		{Detail: ActivityDetail{Activity: Activity{ID: "i3", Name: "Hill Repeats", Feel: 3, Description: " legs were heavy "}}},
+	}
+
+	got := engine.PlanAdoption(winner, donors)
+
+	wantUpdates := map[string]interface{}{"name": "Hill Repeats", "feel": 2, "description": "legs were heavy"}
+	wantSources := map[string]string{"name": "i3", "feel": "i2", "description": "i3"}
+	if !reflect.DeepEqual(got.Updates, wantUpdates) {
+		t.Errorf("Updates = %v; want %v", got.Updates, wantUpdates)
+	}
+	if !reflect.DeepEqual(got.Sources, wantSources) {
+		t.Errorf("Sources = %v; want %v", got.Sources, wantSources)
+	}
+	if reasons := metadataReasons(got.Metadata()); reasons != "Feel: 2, Description" {
+		t.Errorf("metadataReasons = %q", reasons)
+	}
+}
+
+func TestProposeDecision(t *testing.T) {
+	run := &Run{
+		Config:    &Config{},
+		Scoring:   NewScoringEngine(&Config{}),
+		Protector: mustProtector(t, ProtectConfig{IDs: []string{"i3"}}),
+	}
+	g := &ReviewGroup{Members: []evaluatedActivity{
+		{Detail: ActivityDetail{Activity: Activity{ID: "i1", Type: "Ride", Distance: 40000, MovingTime: 5400}}},
+		{Detail: ActivityDetail{Activity: Activity{ID: "i2", Type: "Ride", Distance: 39900, MovingTime: 5390}}},
+		{Detail: ActivityDetail{Activity: Activity{ID: "i3", Type: "Ride", Distance: 40000, MovingTime: 5400}}},
+		{Detail: ActivityDetail{Activity: Activity{ID: "i4", Type: "Ride", Distance: 10000, MovingTime: 1200}}},
+	}}
+
+	// Choosing a lower-ranked winner deletes the ranked winner instead, but
+	// never the protected member or the size mismatch
+	d := run.ProposeDecision(g, "i2")
+	if d.WinnerID != "i2" {
+		t.Errorf("WinnerID = %s; want i2", d.WinnerID)
+	}
+	if want := map[string]bool{"i1": true}; !reflect.DeepEqual(d.Delete, want) {
+		t.Errorf("Delete = %v; want %v", d.Delete, want)
+	}
+
+	// Unknown IDs fall back to the ranked winner
+	if d := run.ProposeDecision(g, "nope"); d.WinnerID != "i1" {
+		t.Errorf("fallback WinnerID = %s; want i1", d.WinnerID)
+	}
+}
+
+func mustProtector(t *testing.T, config ProtectConfig) *Protector {
+	t.Helper()
+	p, err := NewProtector(config)
+	if err != nil {
+		t.Fatalf("NewProtector error: %v", err)
+	}
+	return p
+}
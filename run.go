@@ -0,0 +1,439 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Run holds everything needed to de-duplicate one athlete's activities
+type Run struct {
+	Client      *IntervalsClient
+	Scoring     *ScoringEngine
+	Config      *Config
+	Protector   *Protector
+	Ignored     *IgnoreList
+	Audit       *AuditLog
+	Output      OutputWriter
+	Progress    io.Writer // Human-readable progress and prompts
+	Report      *RunReport
+	DryRun      bool
+	Interactive bool
+
+	// Deleted tracks activities removed during this run so later passes skip them
+	Deleted map[string]bool
+	// Failed holds the members of groups whose details couldn't all be fetched
+	// and the activities of failed deletions, updates and stitches, so watch
+	// retries them
+	Failed map[string]bool
+	// SplitHints maps losers kept under the split mismatch policy to the group
+	// winner, which split detection treats as their complete recording
+	SplitHints map[string]string
+}
+
+// NewRun prepares a run from the config, loading protect rules and the ignore list
+func NewRun(config *Config, client *IntervalsClient, dryRun, interactive bool) (*Run, error) {
+	protector, err := NewProtector(config.Protect)
+	if err != nil {
+		return nil, fmt.Errorf("loading protect rules: %w", err)
+	}
+	ignored, err := LoadIgnoreList(config.IgnoreFile())
+	if err != nil {
+		return nil, fmt.Errorf("loading ignore list: %w", err)
+	}
+
+	return &Run{
+		Client:      client,
+		Scoring:     NewScoringEngine(config),
+		Config:      config,
+		Protector:   protector,
+		Ignored:     ignored,
+		Audit:       NewAuditLog(config.AuditFile()),
+		Output:      &humanOutput{w: os.Stdout},
+		Progress:    os.Stdout,
+		Report:      &RunReport{AthleteID: config.AthleteID, Athlete: config.Name, DryRun: dryRun},
+		DryRun:      dryRun,
+		Interactive: interactive,
+		Deleted:     make(map[string]bool),
+		Failed:      make(map[string]bool),
+		SplitHints:  make(map[string]string),
+	}, nil
+}
+
+// Process groups the activities, resolves each duplicate group and then
+// handles split recordings
+func (r *Run) Process(activities []Activity) {
+	r.Report.Scanned += len(activities)
+
+	for _, group := range groupActivities(activities) {
+		r.processGroup(group)
+	}
+
+	r.handleSplits(activities)
+	r.Output.Finish(r.Report)
+}
+
+// recordAction writes a mutation to the audit log and the run report
+func (r *Run) recordAction(actions *[]ActionReport, entry AuditEntry, err error) {
+	if werr := r.Audit.Record(entry, err); werr != nil {
+		fmt.Fprintf(r.Progress, "    ⚠️ Failed to write audit log: %v\n", werr)
+	}
+	if err != nil {
+		if entry.ActivityID != "" {
+			r.Failed[entry.ActivityID] = true
+		}
+		// A failed stitch has no activity yet; retry its fragments
+		if fragments, ok := entry.Before.([]Activity); ok {
+			for _, f := range fragments {
+				r.Failed[f.ID] = true
+			}
+		}
+	}
+	changes, _ := entry.After.(map[string]interface{})
+	*actions = append(*actions, newActionReport(entry.ActivityID, entry.Action, changes, entry.DryRun, err))
+}
+
+// finishGroup adds a processed group to the report and emits it
+func (r *Run) finishGroup(report *GroupReport) {
+	if len(report.Members) == 0 && !report.Ignored {
+		return
+	}
+	r.Report.Groups = append(r.Report.Groups, report)
+	r.Output.Group(report)
+}
+
+// processGroup scores a duplicate group, adopts names/metadata into the winner
+// and deletes the losers
+func (r *Run) processGroup(group []Activity) {
+	first := group[0]
+	fmt.Fprintf(r.Progress, "\n🚩 Found %d suspected duplicates starting around: %s\n", len(group), first.StartDateLocal.Time.Format("2006-01-02 15:04:05"))
+
+	report := &GroupReport{AthleteID: r.Config.AthleteID, ID: groupKey(activityIDs(group)), Start: first.StartDateLocal.Time}
+	defer r.finishGroup(report)
+
+	if r.Ignored.Contains(activityIDs(group)) {
+		fmt.Fprintf(r.Progress, "  🙈 Skipping ignored group (%s)\n", strings.Join(activityIDs(group), ", "))
+		report.Ignored = true
+		return
+	}
+
+	details := fetchDetails(r.Progress, r.Client, group)
+	if len(details) < len(group) {
+		for _, a := range group {
+			r.Failed[a.ID] = true
+		}
+	}
+	if len(details) <= 1 {
+		return
+	}
+
+	evaluated := rankDetails(r.Scoring, details)
+	decidedBy := r.Scoring.DecidingFactor(&evaluated[0], &evaluated[1])
+
+	// Let the user overrule the scorer before anything is adopted or deleted
+	if r.Interactive {
+		if choice := chooseWinner(r.Progress, evaluated); choice > 0 {
+			recordDecision(r.Progress, r.Config, newDecisionRecord(r.Config.Weights, "winner", false, evaluated[0].Detail.ID, evaluated[choice].Detail.ID, evaluated))
+			evaluated = promoteWinner(evaluated, choice)
+			decidedBy = "manual"
+		}
+	}
+
+	winner := evaluated[0]
+	losers := evaluated[1:]
+
+	winnerSystem := describeSystem(&winner.Detail)
+
+	fmt.Fprintf(r.Progress, "  🏆 Winner: [%s] (ID: %s, Score: %.2f) - %s (%s, %s)\n",
+		winnerSystem, winner.Detail.ID, winner.Score.Total, winner.Detail.Name,
+		formatDistance(winner.Detail.Distance), formatDuration(winner.Detail.MovingTime))
+	for _, reason := range winner.Score.Reasonings {
+		fmt.Fprintf(r.Progress, "    - %s\n", reason)
+	}
+	report.WinnerID = winner.Detail.ID
+	report.DecidedBy = decidedBy
+	switch decidedBy {
+	case "score":
+	case "manual":
+		fmt.Fprintf(r.Progress, "    👤 Winner chosen manually\n")
+	default:
+		fmt.Fprintf(r.Progress, "    ⚖️  Tied on score with %s; decided by tie-breaker: %s\n", evaluated[1].Detail.ID, decidedBy)
+	}
+
+	// --- Dual-Recording Power Comparison ---
+	var members []*ActivityDetail
+	for i := range evaluated {
+		members = append(members, &evaluated[i].Detail)
+	}
+	reference, comparisons := ComparePower(members)
+	if len(comparisons) > 0 {
+		report.PowerRef, report.Power = reference.ID, comparisons
+		printPowerComparison(r.Progress, reference, comparisons)
+	}
+
+	// --- Mismatch Assessment ---
+	// Size mismatches are resolved up front so the policy can decide whether
+	// a loser may donate its name/metadata as well as whether it is deleted.
+	rule := r.Config.MismatchRuleFor(winner.Detail.Type)
+	mismatches, donors := assessLosers(rule, &winner.Detail, losers)
+	adoption := r.Scoring.PlanAdoption(&winner.Detail, donors)
+
+	for i, e := range evaluated {
+		member := newMemberReport(i+1, e)
+		member.Plan = PlanKeep
+		if i > 0 {
+			m := mismatches[i-1]
+			member.DistDiff, member.TimeDiff = m.DistDiff, m.TimeDiff
+			member.DistMismatch, member.TimeMismatch = m.DistMismatch, m.TimeMismatch
+			member.Plan = PlanDelete
+		}
+		report.Members = append(report.Members, member)
+	}
+
+	// --- Name Adoption Logic ---
+	if name, ok := adoption.Updates["name"]; ok {
+		bestName := name.(string)
+
+		adoptConfirmed := !r.Interactive
+		if r.Interactive {
+			adoptConfirmed = confirm(r.Progress, fmt.Sprintf("    Adopt descriptive name \"%s\" for %s?", bestName, winner.Detail.ID), true)
+			recordDecision(r.Progress, r.Config, newDecisionRecord(r.Config.Weights, "name", adoptConfirmed, winner.Detail.ID, "", evaluated))
+			if !adoptConfirmed {
+				report.Actions = append(report.Actions, ActionReport{ActivityID: winner.Detail.ID, Action: AuditUpdateName, Status: StatusDeclined})
+			}
+		}
+
+		if adoptConfirmed {
+			updates := map[string]interface{}{"name": bestName}
+			entry := AuditEntry{
+				ActivityID: winner.Detail.ID,
+				Action:     AuditUpdateName,
+				Before:     map[string]interface{}{"name": winner.Detail.Name},
+				After:      updates,
+				WinnerID:   winner.Detail.ID,
+				Scorecards: auditScorecards(evaluated),
+				DryRun:     r.DryRun,
+			}
+			if r.DryRun {
+				fmt.Fprintf(r.Progress, "    [DRY RUN] Would adopt name \"%s\" for %s\n", bestName, winner.Detail.ID)
+				r.recordAction(&report.Actions, entry, nil)
+			} else {
+				fmt.Fprintf(r.Progress, "    Adopting name \"%s\"...\n", bestName)
+				err := r.Client.UpdateActivity(winner.Detail.ID, updates)
+				r.recordAction(&report.Actions, entry, err)
+				if err != nil {
+					fmt.Fprintf(r.Progress, "    ❌ Error updating name: %v\n", err)
+				} else {
+					fmt.Fprintf(r.Progress, "    ✅ Name updated\n")
+				}
+			}
+		}
+	}
+
+	// --- Metadata Adoption Logic (Feel, RPE, Description) ---
+	metaUpdates := adoption.Metadata()
+	metaBefore := map[string]interface{}{
+		"feel":        winner.Detail.Feel,
+		"icu_rpe":     winner.Detail.RPE,
+		"description": winner.Detail.Description,
+	}
+
+	if len(metaUpdates) > 0 {
+		migrateConfirmed := !r.Interactive
+		msg := metadataReasons(metaUpdates)
+		if r.Interactive {
+			migrateConfirmed = confirm(r.Progress, fmt.Sprintf("    Adopt metadata (%s) for %s?", msg, winner.Detail.ID), true)
+			recordDecision(r.Progress, r.Config, newDecisionRecord(r.Config.Weights, "metadata", migrateConfirmed, winner.Detail.ID, "", evaluated))
+			if !migrateConfirmed {
+				report.Actions = append(report.Actions, ActionReport{ActivityID: winner.Detail.ID, Action: AuditUpdateMetadata, Status: StatusDeclined})
+			}
+		}
+
+		if migrateConfirmed {
+			before := make(map[string]interface{})
+			for k := range metaUpdates {
+				before[k] = metaBefore[k]
+			}
+			entry := AuditEntry{
+				ActivityID: winner.Detail.ID,
+				Action:     AuditUpdateMetadata,
+				Before:     before,
+				After:      metaUpdates,
+				WinnerID:   winner.Detail.ID,
+				Scorecards: auditScorecards(evaluated),
+				DryRun:     r.DryRun,
+			}
+			if r.DryRun {
+				fmt.Fprintf(r.Progress, "    [DRY RUN] Would adopt metadata (%s) for %s\n", msg, winner.Detail.ID)
+				r.recordAction(&report.Actions, entry, nil)
+			} else {
+				fmt.Fprintf(r.Progress, "    Adopting metadata (%s)...\n", msg)
+				err := r.Client.UpdateActivity(winner.Detail.ID, metaUpdates)
+				r.recordAction(&report.Actions, entry, err)
+				if err != nil {
+					fmt.Fprintf(r.Progress, "    ❌ Error updating metadata: %v\n", err)
+				} else {
+					fmt.Fprintf(r.Progress, "    ✅ Metadata updated\n")
+				}
+			}
+		}
+	}
+
+	prompted, declined := 0, 0
+	for i, loser := range losers {
+		loserSystem := describeSystem(&loser.Detail)
+		mismatch := mismatches[i]
+		member := report.Member(loser.Detail.ID)
+
+		if mismatch.IsMismatch() {
+			fmt.Fprintf(r.Progress, "  ⚠️  Mismatch: [%s] (ID: %s, Score: %.2f) - %s (%s, %s)%s\n",
+				loserSystem, loser.Detail.ID, loser.Score.Total, loser.Detail.Name,
+				formatDistance(loser.Detail.Distance), formatDuration(loser.Detail.MovingTime), mismatch.Warnings())
+
+			switch rule.Policy {
+			case MismatchFlag:
+				fmt.Fprintf(r.Progress, "    🚩 Flagged only, not deleting %s: size differs from the winner\n", loser.Detail.ID)
+			case MismatchAdopt:
+				fmt.Fprintf(r.Progress, "    ⏭️  Keeping %s (metadata adopted) due to size difference.\n", loser.Detail.ID)
+			case MismatchSplit:
+				if !coversFragment(&winner.Detail.Activity, &loser.Detail.Activity) {
+					fmt.Fprintf(r.Progress, "    🚩 Flagged only, not deleting %s: %s does not cover it as a complete recording\n", loser.Detail.ID, winner.Detail.ID)
+					member.Plan = PlanFlagged
+					continue
+				}
+				fmt.Fprintf(r.Progress, "    🧩 %s is a split recording candidate; keeping both.\n", loser.Detail.ID)
+				r.SplitHints[loser.Detail.ID] = winner.Detail.ID
+			default:
+				fmt.Fprintf(r.Progress, "    ⏭️  Skipping deletion recommendation for %s due to size difference.\n", loser.Detail.ID)
+			}
+			member.Plan = rule.Policy.Plan()
+			continue
+		}
+
+		if reason := r.Protector.Check(&loser.Detail.Activity); reason != "" {
+			fmt.Fprintf(r.Progress, "  🛡️  Protected: [%s] (ID: %s, Score: %.2f) - %s (%s, %s)\n",
+				loserSystem, loser.Detail.ID, loser.Score.Total, loser.Detail.Name,
+				formatDistance(loser.Detail.Distance), formatDuration(loser.Detail.MovingTime))
+			fmt.Fprintf(r.Progress, "    🚩 Flagged only, not deleting %s: %s\n", loser.Detail.ID, reason)
+			member.Protected = reason
+			member.Plan = PlanProtected
+			continue
+		}
+
+		fmt.Fprintf(r.Progress, "  🗑️  To Delete: [%s] (ID: %s, Score: %.2f) - %s (%s, %s)\n",
+			loserSystem, loser.Detail.ID, loser.Score.Total, loser.Detail.Name,
+			formatDistance(loser.Detail.Distance), formatDuration(loser.Detail.MovingTime))
+
+		for _, reason := range loser.Score.Reasonings {
+			fmt.Fprintf(r.Progress, "    - %s\n", reason)
+		}
+
+		deleteConfirmed := !r.Interactive
+		if r.Interactive {
+			deleteConfirmed = confirm(r.Progress, fmt.Sprintf("    Confirm deletion of %s?", loser.Detail.ID), false)
+			prompted++
+			if !deleteConfirmed {
+				declined++
+			}
+			recordDecision(r.Progress, r.Config, newDecisionRecord(r.Config.Weights, "delete", deleteConfirmed, winner.Detail.ID, loser.Detail.ID, evaluated))
+		}
+
+		if deleteConfirmed {
+			entry := AuditEntry{
+				ActivityID: loser.Detail.ID,
+				Action:     AuditDelete,
+				Before:     loser.Detail,
+				WinnerID:   winner.Detail.ID,
+				Scorecards: auditScorecards(evaluated),
+				DryRun:     r.DryRun,
+			}
+			if r.DryRun {
+				fmt.Fprintf(r.Progress, "    [DRY RUN] Would delete %s\n", loser.Detail.ID)
+				r.recordAction(&report.Actions, entry, nil)
+			} else {
+				fmt.Fprintf(r.Progress, "    Deleting %s...\n", loser.Detail.ID)
+				err := r.Client.DeleteActivity(loser.Detail.ID)
+				r.recordAction(&report.Actions, entry, err)
+				if err != nil {
+					fmt.Fprintf(r.Progress, "    ❌ Error deleting %s: %v\n", loser.Detail.ID, err)
+				} else {
+					r.Deleted[loser.Detail.ID] = true
+					fmt.Fprintf(r.Progress, "    ✅ Deleted %s\n", loser.Detail.ID)
+				}
+			}
+		} else {
+			fmt.Fprintf(r.Progress, "    ⏭️  Skipped deletion of %s\n", loser.Detail.ID)
+			report.Actions = append(report.Actions, ActionReport{ActivityID: loser.Detail.ID, Action: AuditDelete, Status: StatusDeclined})
+		}
+	}
+
+	// Remember groups where every deletion was declined so they aren't asked again
+	if prompted > 0 && declined == prompted {
+		r.Ignored.Add(activityIDs(group), "declined interactively")
+		if err := r.Ignored.Save(); err != nil {
+			fmt.Fprintf(r.Progress, "  ⚠️ Failed to save ignore list: %v\n", err)
+		} else {
+			fmt.Fprintf(r.Progress, "  🙈 Added group to ignore list (use 'ignore remove' to review it again)\n")
+		}
+	}
+}
+
+// chooseWinner lists the ranked members and asks which one to keep, returning
+// its index. An empty answer keeps the scorer's choice.
+func chooseWinner(w io.Writer, evaluated []evaluatedActivity) int {
+	fmt.Fprintf(w, "  Ranked recordings:\n")
+	for i, e := range evaluated {
+		fmt.Fprintf(w, "    %d. [%s] (ID: %s, Score: %.2f) - %s (%s, %s)\n", i+1,
+			describeSystem(&e.Detail), e.Detail.ID, e.Score.Total, e.Detail.Name,
+			formatDistance(e.Detail.Distance), formatDuration(e.Detail.MovingTime))
+	}
+	for {
+		fmt.Fprintf(w, "    Keep which recording? [1-%d, enter for 1]: ", len(evaluated))
+		response, err := stdin.ReadString('\n')
+		response = strings.TrimSpace(response)
+		if response == "" {
+			return 0
+		}
+		if n, convErr := strconv.Atoi(response); convErr == nil && n >= 1 && n <= len(evaluated) {
+			return n - 1
+		}
+		if err != nil {
+			return 0
+		}
+		fmt.Fprintf(w, "    Please enter a number between 1 and %d\n", len(evaluated))
+	}
+}
+
+// promoteWinner moves the chosen member to the front, keeping the others in rank order
+func promoteWinner(evaluated []evaluatedActivity, choice int) []evaluatedActivity {
+	reordered := []evaluatedActivity{evaluated[choice]}
+	for i, e := range evaluated {
+		if i != choice {
+			reordered = append(reordered, e)
+		}
+	}
+	return reordered
+}
+
+// newMemberReport describes a ranked group member
+func newMemberReport(rank int, e evaluatedActivity) *MemberReport {
+	uploader := e.Detail.Source
+	if uploader == "OAUTH_CLIENT" && e.Detail.OAuthClientName != "" {
+		uploader = e.Detail.OAuthClientName
+	}
+	return &MemberReport{
+		ID:         e.Detail.ID,
+		Name:       e.Detail.Name,
+		Type:       e.Detail.Type,
+		Device:     e.Detail.DeviceName,
+		Uploader:   uploader,
+		Distance:   e.Detail.Distance,
+		MovingTime: e.Detail.MovingTime,
+		Rank:       rank,
+		Score:      e.Score.Total,
+		Breakdown:  e.Score.Breakdown,
+		Reasonings: e.Score.Reasonings,
+	}
+}
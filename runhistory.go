@@ -0,0 +1,414 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultHistoryFile = "history.jsonl"
+
+// RunRecord is a finished run stored in the run history file: every group
+// with its members' scorecards, the decision, and the result of each mutation
+type RunRecord struct {
+	Time time.Time `json:"time"`
+	Mode string    `json:"mode"` // "run", "watch", "webhook", or "serve"/"review" for decisions applied in the review UIs
+	*RunReport
+	Summary RunSummary `json:"summary"`
+}
+
+// HistoryFile returns the configured run history path, or the default
+func (c *Config) HistoryFile() string {
+	if c.HistoryPath != "" {
+		return c.HistoryPath
+	}
+	return defaultHistoryFile
+}
+
+// HistoryLog is an append-only JSONL file of run records, like the audit log.
+// Appends are serialized so concurrent athletes' runs don't interleave. There
+// is no index: every query scans the whole file, decoding only the runs it
+// returns.
+type HistoryLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewHistoryLog(path string) *HistoryLog {
+	return &HistoryLog{path: path}
+}
+
+// Append stores a finished run
+func (h *HistoryLog) Append(record RunRecord) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return appendJSONLine(h.path, record)
+}
+
+// Query reads the athlete's runs that finished within the window, oldest first.
+// A missing file yields no records.
+func (h *HistoryLog) Query(athleteID string, oldest, newest time.Time) ([]RunRecord, error) {
+	f, err := os.Open(h.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []RunRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		// Check the run's athlete and time before decoding its groups
+		var head struct {
+			Time      time.Time `json:"time"`
+			AthleteID string    `json:"athlete_id"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &head); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", h.path, line, err)
+		}
+		if head.AthleteID != athleteID || head.Time.Before(oldest) || head.Time.After(newest) {
+			continue
+		}
+		var r RunRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", h.path, line, err)
+		}
+		records = append(records, r)
+	}
+	return records, scanner.Err()
+}
+
+// historyOutput stores each finished run in its athlete's history before
+// passing it on
+type historyOutput struct {
+	OutputWriter
+	logs     map[string]*HistoryLog // By athlete ID
+	mode     string
+	progress io.Writer // Where storage failures are reported
+}
+
+// newHistoryOutput opens the athletes' run history files, sharing one between
+// athletes configured with the same file
+func newHistoryOutput(w OutputWriter, athletes []*Config, mode string, progress io.Writer) historyOutput {
+	byPath := make(map[string]*HistoryLog)
+	logs := make(map[string]*HistoryLog)
+	for _, a := range athletes {
+		path := a.HistoryFile()
+		if byPath[path] == nil {
+			byPath[path] = NewHistoryLog(path)
+		}
+		logs[a.AthleteID] = byPath[path]
+	}
+	return historyOutput{w, logs, mode, progress}
+}
+
+func (o historyOutput) Finish(r *RunReport) {
+	h, ok := o.logs[r.AthleteID]
+	if !ok {
+		o.OutputWriter.Finish(r)
+		return
+	}
+	if err := h.Append(RunRecord{Time: time.Now(), Mode: o.mode, RunReport: r, Summary: r.Summary()}); err != nil {
+		fmt.Fprintf(o.progress, "⚠️  Failed to record run history: %v\n", err)
+	}
+	o.OutputWriter.Finish(r)
+}
+
+// HistoryEvent is what one run did with an activity
+type HistoryEvent struct {
+	Time      time.Time
+	DryRun    bool
+	Group     *GroupReport // Set when the activity was in a duplicate group
+	Split     *SplitReport // Set when the activity was part of a split recording
+	Member    *MemberReport
+	Actions   []ActionReport // Mutations of the activity
+	DecidedBy string
+}
+
+// recordReview stores the groups applied in a review UI in the athlete's history
+// like a run, so history and stats include them
+func recordReview(run *Run, mode string, groups []*GroupReport) error {
+	if len(groups) == 0 {
+		return nil
+	}
+	report := &RunReport{
+		AthleteID: run.Config.AthleteID,
+		Athlete:   run.Config.Name,
+		Oldest:    run.Report.Oldest,
+		Newest:    run.Report.Newest,
+		DryRun:    run.DryRun,
+		Groups:    groups,
+	}
+	for _, g := range groups {
+		g.AthleteID, g.DecidedBy = run.Config.AthleteID, "manual"
+	}
+	return NewHistoryLog(run.Config.HistoryFile()).Append(RunRecord{Time: time.Now(), Mode: mode, RunReport: report, Summary: report.Summary()})
+}
+
+// ActivityHistory finds every run that saw the activity, oldest first
+func ActivityHistory(records []RunRecord, id string) []HistoryEvent {
+	var events []HistoryEvent
+	forActivity := func(actions []ActionReport) []ActionReport {
+		var matched []ActionReport
+		for _, a := range actions {
+			if a.ActivityID == id {
+				matched = append(matched, a)
+			}
+		}
+		return matched
+	}
+
+	for _, r := range records {
+		if r.RunReport == nil {
+			continue
+		}
+		for _, g := range r.Groups {
+			if m := g.Member(id); m != nil {
+				events = append(events, HistoryEvent{
+					Time: r.Time, DryRun: r.DryRun, Group: g, Member: m,
+					Actions: forActivity(g.Actions), DecidedBy: g.DecidedBy,
+				})
+			}
+		}
+		for _, s := range r.Splits {
+			related := s.CompleteID == id
+			for _, f := range s.Fragments {
+				related = related || f == id
+			}
+			for _, a := range s.Actions {
+				related = related || a.ActivityID == id
+			}
+			if related {
+				events = append(events, HistoryEvent{Time: r.Time, DryRun: r.DryRun, Split: s, Actions: forActivity(s.Actions)})
+			}
+		}
+	}
+	return events
+}
+
+// DeviceOutcome counts how often a device's recordings won or lost groups
+type DeviceOutcome struct {
+	Device  string
+	Won     int
+	Deleted int // Losses that led to a deletion
+}
+
+// HistoryStats aggregates the stored runs
+type HistoryStats struct {
+	Runs      int
+	DryRuns   int
+	First     time.Time
+	Last      time.Time
+	Totals    RunSummary
+	DecidedBy map[string]int
+	Devices   []DeviceOutcome // Most involved first
+}
+
+// BuildHistoryStats sums the outcome of the runs
+func BuildHistoryStats(records []RunRecord) HistoryStats {
+	stats := HistoryStats{DecidedBy: make(map[string]int)}
+	devices := make(map[string]*DeviceOutcome)
+	device := func(name string) *DeviceOutcome {
+		name = valueOr(name, "(unknown)")
+		if devices[name] == nil {
+			devices[name] = &DeviceOutcome{Device: name}
+		}
+		return devices[name]
+	}
+
+	for _, r := range records {
+		if r.RunReport == nil {
+			continue
+		}
+		stats.Runs++
+		if r.DryRun {
+			stats.DryRuns++
+		}
+		if stats.First.IsZero() || r.Time.Before(stats.First) {
+			stats.First = r.Time
+		}
+		if r.Time.After(stats.Last) {
+			stats.Last = r.Time
+		}
+		stats.Totals = stats.Totals.Add(r.Summary)
+
+		for _, g := range r.Groups {
+			if g.Ignored || g.WinnerID == "" {
+				continue
+			}
+			stats.DecidedBy[valueOr(g.DecidedBy, "score")]++
+			for _, m := range g.Members {
+				if m.ID == g.WinnerID {
+					device(m.Device).Won++
+				}
+			}
+			for _, a := range g.Actions {
+				if a.Action == AuditDelete && a.Status == StatusOK {
+					if m := g.Member(a.ActivityID); m != nil {
+						device(m.Device).Deleted++
+					}
+				}
+			}
+		}
+	}
+
+	for _, d := range devices {
+		stats.Devices = append(stats.Devices, *d)
+	}
+	sort.Slice(stats.Devices, func(i, j int) bool {
+		a, b := stats.Devices[i], stats.Devices[j]
+		if a.Won+a.Deleted != b.Won+b.Deleted {
+			return a.Won+a.Deleted > b.Won+b.Deleted
+		}
+		return a.Device < b.Device
+	})
+	return stats
+}
+
+// historyWindow searches every recorded run unless --days, --start or --end
+// narrowed the window
+func historyWindow(opts options, oldest, newest time.Time) (time.Time, time.Time) {
+	if opts.days > 0 || opts.start != "" || opts.end != "" {
+		return oldest, newest
+	}
+	return time.Time{}, time.Now()
+}
+
+// printNoRuns reports an empty history, naming the window when one was given
+func printNoRuns(oldest, newest time.Time) {
+	if oldest.IsZero() {
+		fmt.Println("No runs recorded.")
+		return
+	}
+	fmt.Printf("No runs recorded between %s and %s.\n", oldest.Format("2006-01-02"), newest.Format("2006-01-02"))
+}
+
+func loadHistory(config *Config, oldest, newest time.Time) []RunRecord {
+	records, err := NewHistoryLog(config.HistoryFile()).Query(config.AthleteID, oldest, newest)
+	if err != nil {
+		log.Fatalf("Error loading history: %v", err)
+	}
+	return records
+}
+
+// runHistory prints what happened to an activity, or the recent runs when no
+// activity ID is given
+func runHistory(config *Config, args []string, oldest, newest time.Time) {
+	if len(args) > 1 {
+		log.Fatalf("Usage: intervals-deduper history [activity-id]")
+	}
+	records := loadHistory(config, oldest, newest)
+
+	if len(args) == 0 {
+		if len(records) == 0 {
+			printNoRuns(oldest, newest)
+			return
+		}
+		fmt.Printf("%-16s %-8s %-23s %7s %6s %7s %7s %7s %6s\n", "TIME", "MODE", "WINDOW", "SCANNED", "GROUPS", "DELETED", "UPDATED", "PLANNED", "ERRORS")
+		for _, r := range records {
+			s := r.Summary
+			fmt.Printf("%-16s %-8s %-23s %7d %6d %7d %7d %7d %6d\n",
+				r.Time.Format("2006-01-02 15:04"), valueOr(r.Mode, "run"),
+				r.Oldest.Format("2006-01-02")+" – "+r.Newest.Format("2006-01-02"),
+				s.Scanned, s.Groups, s.Deleted, s.Updated, s.Planned, s.Errors)
+		}
+		return
+	}
+
+	id := args[0]
+	events := ActivityHistory(records, id)
+	if len(events) == 0 {
+		fmt.Printf("No recorded runs involved %s.\n", id)
+		return
+	}
+	fmt.Printf("📜 History of %s\n", id)
+	for _, e := range events {
+		dry := ""
+		if e.DryRun {
+			dry = " (dry run)"
+		}
+		fmt.Printf("\n%s%s\n", e.Time.Format("2006-01-02 15:04:05"), dry)
+		if e.Group != nil {
+			m := e.Member
+			fmt.Printf("   Group %s: rank %d of %d, score %.1f, plan %s\n", e.Group.ID, m.Rank, len(e.Group.Members), m.Score, m.Plan)
+			if m.ID == e.Group.WinnerID {
+				fmt.Printf("   🏆 Winner (decided by %s)\n", valueOr(e.DecidedBy, "score"))
+			} else {
+				fmt.Printf("   Winner: %s (decided by %s)\n", e.Group.WinnerID, valueOr(e.DecidedBy, "score"))
+			}
+			if m.Protected != "" {
+				fmt.Printf("   🛡 Protected: %s\n", m.Protected)
+			}
+		}
+		if e.Split != nil {
+			fmt.Printf("   Split recording: fragments %s", strings.Join(e.Split.Fragments, ", "))
+			if e.Split.CompleteID != "" {
+				fmt.Printf(", complete recording %s", e.Split.CompleteID)
+			}
+			fmt.Println()
+		}
+		for _, a := range e.Actions {
+			fmt.Printf("   → %s: %s", a.Action, a.Status)
+			if a.Error != "" {
+				fmt.Printf(" (%s)", a.Error)
+			}
+			fmt.Println()
+		}
+	}
+}
+
+// runStats prints totals across the stored runs, optionally limited to a window
+func runStats(config *Config, oldest, newest time.Time) {
+	stats := BuildHistoryStats(loadHistory(config, oldest, newest))
+	if stats.Runs == 0 {
+		printNoRuns(oldest, newest)
+		return
+	}
+
+	t := stats.Totals
+	fmt.Printf("📈 %d runs (%d dry runs) from %s to %s\n\n", stats.Runs, stats.DryRuns, stats.First.Format("2006-01-02 15:04"), stats.Last.Format("2006-01-02 15:04"))
+	fmt.Printf("   Activities scanned:      %d\n", t.Scanned)
+	fmt.Printf("   Duplicate groups:        %d\n", t.Groups)
+	fmt.Printf("   Split recordings:        %d\n", t.Splits)
+	fmt.Printf("   Deleted:                 %d\n", t.Deleted)
+	fmt.Printf("   Names/metadata adopted:  %d\n", t.Updated)
+	fmt.Printf("   Uploaded:                %d\n", t.Uploaded)
+	fmt.Printf("   Planned (dry run):       %d\n", t.Planned)
+	fmt.Printf("   Declined:                %d\n", t.Declined)
+	fmt.Printf("   Kept due to mismatch:    %d\n", t.MismatchSkipped)
+	fmt.Printf("   Protected:               %d\n", t.Protected)
+	fmt.Printf("   Errors:                  %d\n", t.Errors)
+
+	if len(stats.DecidedBy) > 0 {
+		fmt.Println("\n   Winners decided by:")
+		var keys []string
+		for k := range stats.DecidedBy {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Printf("     %-22s %d\n", k, stats.DecidedBy[k])
+		}
+	}
+
+	if len(stats.Devices) > 0 {
+		fmt.Printf("\n   %-30s %5s %8s\n", "DEVICE", "WON", "DELETED")
+		for _, d := range stats.Devices {
+			fmt.Printf("   %-30s %5d %8d\n", d.Device, d.Won, d.Deleted)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func historyTestReport() *RunReport {
+	return &RunReport{
+		AthleteID: "i0",
+		Scanned:   3,
+		Groups: []*GroupReport{{
+			ID:        "i1,i2",
+			WinnerID:  "i1",
+			DecidedBy: "score",
+			Members: []*MemberReport{
+				{ID: "i1", Device: "Garmin Edge 530", Rank: 1, Score: 20, Plan: PlanKeep},
+				{ID: "i2", Device: "Phone", Rank: 2, Score: 7, Plan: PlanDelete},
+			},
+			Actions: []ActionReport{{ActivityID: "i2", Action: AuditDelete, Status: StatusOK}},
+		}},
+		Splits: []*SplitReport{{Fragments: []string{"i3", "i4"}, Actions: []ActionReport{{ActivityID: "i9", Action: AuditUpload, Status: StatusOK}}}},
+	}
+}
+
+func TestHistoryLogRoundTrip(t *testing.T) {
+	history := NewHistoryLog(filepath.Join(t.TempDir(), "history.jsonl"))
+	window := func() ([]RunRecord, error) {
+		return history.Query("i0", time.Time{}, time.Now())
+	}
+	if records, err := window(); err != nil || len(records) != 0 {
+		t.Fatalf("empty Query = %v, %v", records, err)
+	}
+
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	report := historyTestReport()
+	for i := 0; i < 2; i++ {
+		if err := history.Append(RunRecord{Time: at, Mode: "run", RunReport: report, Summary: report.Summary()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	records, err := window()
+	if err != nil || len(records) != 2 {
+		t.Fatalf("Query = %d records, %v; want 2", len(records), err)
+	}
+	r := records[1]
+	if !r.Time.Equal(at) || r.AthleteID != "i0" || r.Summary.Deleted != 1 || r.Groups[0].Member("i2").Plan != PlanDelete {
+		t.Errorf("unexpected record: %+v", r)
+	}
+}
+
+func TestActivityHistory(t *testing.T) {
+	records := []RunRecord{{Time: time.Unix(100, 0), RunReport: historyTestReport()}}
+
+	tests := []struct {
+		id      string
+		events  int
+		actions int
+		split   bool
+	}{
+		{"i1", 1, 0, false},
+		{"i2", 1, 1, false},
+		{"i3", 1, 0, true},
+		{"i9", 1, 1, true}, // Uploaded stitched recording
+		{"i7", 0, 0, false},
+	}
+	for _, tt := range tests {
+		events := ActivityHistory(records, tt.id)
+		if len(events) != tt.events {
+			t.Errorf("%s: %d events; want %d", tt.id, len(events), tt.events)
+			continue
+		}
+		if tt.events == 0 {
+			continue
+		}
+		if len(events[0].Actions) != tt.actions || (events[0].Split != nil) != tt.split {
+			t.Errorf("%s: event %+v", tt.id, events[0])
+		}
+	}
+}
+
+func TestHistoryLogQuery(t *testing.T) {
+	history := NewHistoryLog(filepath.Join(t.TempDir(), "history.jsonl"))
+	other := historyTestReport()
+	other.AthleteID = "i5"
+	for _, r := range []RunRecord{
+		{Time: time.Unix(100, 0), RunReport: historyTestReport()},
+		{Time: time.Unix(200, 0), RunReport: historyTestReport()},
+		{Time: time.Unix(200, 0), RunReport: other},
+	} {
+		if err := history.Append(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	kept, err := history.Query("i0", time.Unix(150, 0), time.Unix(300, 0))
+	if err != nil || len(kept) != 1 || !kept[0].Time.Equal(time.Unix(200, 0)) {
+		t.Errorf("kept = %+v, %v", kept, err)
+	}
+}
+
+func TestBuildHistoryStats(t *testing.T) {
+	report := historyTestReport()
+	dry := historyTestReport()
+	dry.DryRun = true
+	dry.Groups[0].DecidedBy = "manual"
+	dry.Groups[0].Actions[0].Status = StatusDryRun
+	records := []RunRecord{
+		{Time: time.Unix(100, 0), RunReport: report, Summary: report.Summary()},
+		{Time: time.Unix(200, 0), RunReport: dry, Summary: dry.Summary()},
+	}
+
+	stats := BuildHistoryStats(records)
+	if stats.Runs != 2 || stats.DryRuns != 1 || stats.Totals.Deleted != 1 || stats.Totals.Planned != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if stats.DecidedBy["score"] != 1 || stats.DecidedBy["manual"] != 1 {
+		t.Errorf("decided by = %v", stats.DecidedBy)
+	}
+	want := []DeviceOutcome{{Device: "Garmin Edge 530", Won: 2}, {Device: "Phone", Deleted: 1}}
+	if len(stats.Devices) != len(want) || stats.Devices[0] != want[0] || stats.Devices[1] != want[1] {
+		t.Errorf("devices = %+v; want %+v", stats.Devices, want)
+	}
+}
+
+func TestHistoryWindow(t *testing.T) {
+	oldest, newest := time.Unix(100, 0), time.Unix(200, 0)
+	tests := []struct {
+		opts     options
+		narrowed bool
+	}{
+		{options{}, false},
+		{options{days: 7}, true},
+		{options{start: "2024-05-01"}, true},
+		{options{end: "2024-05-31"}, true},
+	}
+	for _, tt := range tests {
+		gotOldest, gotNewest := historyWindow(tt.opts, oldest, newest)
+		if narrowed := gotOldest.Equal(oldest) && gotNewest.Equal(newest); narrowed != tt.narrowed {
+			t.Errorf("historyWindow(%+v) = %v, %v; want narrowed %v", tt.opts, gotOldest, gotNewest, tt.narrowed)
+		}
+		if !tt.narrowed && !gotOldest.IsZero() {
+			t.Errorf("historyWindow(%+v) oldest = %v; want every run", tt.opts, gotOldest)
+		}
+	}
+}
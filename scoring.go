@@ -4,12 +4,31 @@ import (
 	"fmt"
 	"math"
 	"regexp"
+	"sort"
 	"strings"
 )
 
 var whitespaceRegex = regexp.MustCompile(`\s+`)
 var genericTimeKeywords = []string{"morning", "afternoon", "evening", "night", "lunch"}
 
+// defaultBarometricDevices are device name substrings known to record elevation
+// with a barometric altimeter. Used when barometric_devices is not configured.
+var defaultBarometricDevices = []string{"edge", "elemnt", "karoo", "fenix", "forerunner", "epix", "enduro", "instinct", "coros", "suunto", "polar"}
+
+// gpsAltitudeHints are device/uploader substrings that indicate elevation was
+// derived from GPS (typically phone apps).
+var gpsAltitudeHints = []string{"iphone", "android", "phone", " app"}
+
+// AltitudeSource describes how an activity's elevation data was most likely recorded
+type AltitudeSource string
+
+const (
+	AltitudeNone       AltitudeSource = "none"
+	AltitudeBarometric AltitudeSource = "barometric"
+	AltitudeGPS        AltitudeSource = "gps"
+	AltitudeUnknown    AltitudeSource = "unknown"
+)
+
 type ScoringEngine struct {
 	Config *Config
 }
@@ -93,6 +112,7 @@ func (s *ScoringEngine) RankCandidateNames(names []string, activityType string)
 func (s *ScoringEngine) Score(detail *ActivityDetail) Scorecard {
 	card := Scorecard{
 		Breakdown:  make(map[string]float64),
+		Features:   make(map[string]float64),
 		Reasonings: []string{},
 	}
 
@@ -114,6 +134,12 @@ func (s *ScoringEngine) Score(detail *ActivityDetail) Scorecard {
 	// 6. Uploader Penalties
 	s.evaluateUploader(detail, &card)
 
+	// 7. Altitude Quality
+	s.evaluateAltitude(detail, &card)
+
+	// 8. Power Source Priority
+	s.evaluatePowerSource(detail, &card)
+
 	// Calculate Total
 	for _, score := range card.Breakdown {
 		card.Total += score
@@ -144,21 +170,25 @@ func (s *ScoringEngine) evaluateStreams(detail *ActivityDetail, card *Scorecard)
 	if hasWatts {
 		score := s.Config.Weights.Power
 		card.Breakdown["Power Stream"] = score
+		card.Features["power"] = 1
 		card.Reasonings = append(card.Reasonings, "Contains power/watts data.")
 	}
 	if hasHR {
 		score := s.Config.Weights.HeartRate
 		card.Breakdown["HeartRate Stream"] = score
+		card.Features["heartrate"] = 1
 		card.Reasonings = append(card.Reasonings, "Contains heart rate data.")
 	}
 	if hasGPS {
 		score := s.Config.Weights.GPS
 		card.Breakdown["GPS/Map Stream"] = score
+		card.Features["gps"] = 1
 		card.Reasonings = append(card.Reasonings, "Contains GPS/latlng map data.")
 	}
 	if hasCadence {
 		score := s.Config.Weights.Cadence
 		card.Breakdown["Cadence Stream"] = score
+		card.Features["cadence"] = 1
 		card.Reasonings = append(card.Reasonings, "Contains cadence data.")
 	}
 }
@@ -177,6 +207,7 @@ func (s *ScoringEngine) evaluateSampling(detail *ActivityDetail, card *Scorecard
 
 	score := normalizedRate * s.Config.Weights.SamplingRate
 	card.Breakdown["Sampling Density"] = score
+	card.Features["sampling_rate"] = normalizedRate
 	card.Reasonings = append(card.Reasonings, fmt.Sprintf("Sampling density: %.0f%% of moving time recorded.", normalizedRate*100))
 }
 
@@ -193,22 +224,37 @@ func (s *ScoringEngine) evaluateDevice(detail *ActivityDetail, card *Scorecard)
 			break
 		}
 	}
+
+	// Low-battery power meters tend to drop samples
+	switch strings.ToLower(detail.PowerMeterBattery) {
+	case "low":
+		card.Breakdown["Power Meter Battery"] = -s.Config.Weights.LowBattery
+		card.Features["low_battery"] = -1
+		card.Reasonings = append(card.Reasonings, fmt.Sprintf("Power meter battery low: %s", PowerSourceLabel(detail)))
+	case "critical":
+		card.Breakdown["Power Meter Battery"] = -2 * s.Config.Weights.LowBattery
+		card.Features["low_battery"] = -2
+		card.Reasonings = append(card.Reasonings, fmt.Sprintf("Power meter battery critical: %s", PowerSourceLabel(detail)))
+	}
 }
 
 func (s *ScoringEngine) evaluateInteractions(detail *ActivityDetail, card *Scorecard) {
 	if detail.RPE > 0 {
 		score := s.Config.Weights.RPE
 		card.Breakdown["RPE/Feel"] = score
+		card.Features["rpe"] = 1
 		card.Reasonings = append(card.Reasonings, fmt.Sprintf("User provided RPE: %d", detail.RPE))
 	} else if detail.Feel > 0 {
 		score := s.Config.Weights.RPE
 		card.Breakdown["RPE/Feel"] = score
+		card.Features["rpe"] = 1
 		card.Reasonings = append(card.Reasonings, fmt.Sprintf("User provided Feel: %d", detail.Feel))
 	}
 
 	if strings.TrimSpace(detail.Description) != "" {
 		score := s.Config.Weights.Manual
 		card.Breakdown["Manual Description"] = score
+		card.Features["manual"] = 1
 		card.Reasonings = append(card.Reasonings, "Activity has custom notes/description.")
 	}
 }
@@ -234,6 +280,7 @@ func (s *ScoringEngine) evaluateName(detail *ActivityDetail, card *Scorecard) {
 		if !isPenalized {
 			score := s.Config.Weights.CustomName
 			card.Breakdown["Custom Name"] = score
+			card.Features["custom_name"] = 1
 			card.Reasonings = append(card.Reasonings, fmt.Sprintf("Appears to have a custom name: %s", detail.Name))
 		}
 	}
@@ -252,3 +299,109 @@ func (s *ScoringEngine) evaluateUploader(detail *ActivityDetail, card *Scorecard
 		}
 	}
 }
+
+// Altitude noise thresholds: the median absolute second difference between
+// consecutive samples. Barometric altimeters record smooth, finely resolved
+// elevation; GPS-derived elevation jitters by a metre or more per sample.
+const (
+	minAltitudeSamples   = 60
+	barometricJitterMax  = 0.1
+	gpsAltitudeJitterMin = 0.4
+)
+
+// altitudeJitter returns the median absolute second difference of the samples,
+// or -1 when there are too few to judge
+func altitudeJitter(samples []float64) float64 {
+	if len(samples) < minAltitudeSamples {
+		return -1
+	}
+	diffs := make([]float64, 0, len(samples)-2)
+	for i := 1; i+1 < len(samples); i++ {
+		diffs = append(diffs, math.Abs(samples[i+1]-2*samples[i]+samples[i-1]))
+	}
+	sort.Float64s(diffs)
+	return diffs[len(diffs)/2]
+}
+
+// ClassifyAltitude decides whether elevation came from a barometric altimeter or
+// was derived from GPS. Phone/app hints are checked first (e.g. "Polar Beat
+// iPhone"), then the noise of the altitude samples, then barometric device hints.
+func (s *ScoringEngine) ClassifyAltitude(detail *ActivityDetail) AltitudeSource {
+	if !hasStream(detail, "altitude") {
+		return AltitudeNone
+	}
+
+	searchString := strings.ToLower(fmt.Sprintf("%s %s", detail.DeviceName, detail.OAuthClientName))
+	for _, hint := range gpsAltitudeHints {
+		if strings.Contains(searchString, hint) {
+			return AltitudeGPS
+		}
+	}
+
+	switch jitter := altitudeJitter(detail.Altitude); {
+	case jitter < 0:
+	case jitter <= barometricJitterMax:
+		return AltitudeBarometric
+	case jitter >= gpsAltitudeJitterMin:
+		return AltitudeGPS
+	}
+
+	device := strings.ToLower(detail.DeviceName)
+	barometric := defaultBarometricDevices
+	if s.Config != nil && len(s.Config.BarometricDevices) > 0 {
+		barometric = s.Config.BarometricDevices
+	}
+	for _, hint := range barometric {
+		if device != "" && strings.Contains(device, strings.ToLower(hint)) {
+			return AltitudeBarometric
+		}
+	}
+
+	return AltitudeUnknown
+}
+
+func (s *ScoringEngine) evaluateAltitude(detail *ActivityDetail, card *Scorecard) {
+	switch s.ClassifyAltitude(detail) {
+	case AltitudeBarometric:
+		card.Breakdown["Barometric Altitude"] = s.Config.Weights.Altitude
+		card.Features["altitude"] = 1
+		card.Reasonings = append(card.Reasonings, fmt.Sprintf("Barometric elevation data (%.0fm gain).", detail.TotalElevationGain))
+	case AltitudeGPS:
+		// GPS-derived elevation is noisy and usually overstates gain
+		card.Breakdown["GPS Altitude"] = s.Config.Weights.Altitude * 0.25
+		card.Features["altitude"] = 0.25
+		card.Reasonings = append(card.Reasonings, fmt.Sprintf("GPS-derived elevation data (%.0fm gain).", detail.TotalElevationGain))
+	case AltitudeUnknown:
+		card.Breakdown["Altitude Stream"] = s.Config.Weights.Altitude * 0.5
+		card.Features["altitude"] = 0.5
+		card.Reasonings = append(card.Reasonings, fmt.Sprintf("Elevation data from unclassified source (%.0fm gain).", detail.TotalElevationGain))
+	}
+}
+
+func (s *ScoringEngine) evaluatePowerSource(detail *ActivityDetail, card *Scorecard) {
+	if !hasStream(detail, "watts") {
+		return
+	}
+
+	// Match against the power meter name/serial, falling back to the recording device
+	// (e.g. a smart trainer broadcasting power to a head unit)
+	searchString := strings.ToLower(fmt.Sprintf("%s %s %s", detail.PowerMeter, detail.PowerMeterSerial, detail.DeviceName))
+
+	for i, preferred := range s.Config.PowerMeterPriority {
+		if strings.Contains(searchString, strings.ToLower(preferred)) {
+			bonus := float64(len(s.Config.PowerMeterPriority)-i) * 2.0
+			card.Breakdown["Power Meter Priority: "+preferred] = bonus
+			card.Reasonings = append(card.Reasonings, fmt.Sprintf("Power recorded by trusted meter: %s", preferred))
+			break
+		}
+	}
+}
+
+func hasStream(detail *ActivityDetail, stream string) bool {
+	for _, t := range detail.StreamTypes {
+		if t == stream {
+			return true
+		}
+	}
+	return false
+}
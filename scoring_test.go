@@ -95,3 +95,71 @@ func TestScoring(t *testing.T) {
 		t.Errorf("Expected low quality score (%.2f) to be less than high quality (%.2f)", score2.Total, score1.Total)
 	}
 }
+
+func TestClassifyAltitude(t *testing.T) {
+	s := NewScoringEngine(&Config{})
+
+	// A steady 0.2m/s climb, and the same climb with ±1.5m GPS jitter
+	var smooth, noisy []float64
+	for i := 0; i < 120; i++ {
+		smooth = append(smooth, 100+0.2*float64(i))
+		noisy = append(noisy, 100+0.2*float64(i)+1.5*float64((i*7)%3-1))
+	}
+
+	tests := []struct {
+		device   string
+		streams  []string
+		altitude []float64
+		want     AltitudeSource
+	}{
+		{"Garmin Edge 840", []string{"altitude", "latlng"}, nil, AltitudeBarometric},
+		{"Wahoo ELEMNT BOLT", []string{"altitude"}, nil, AltitudeBarometric},
+		{"Strava iPhone App", []string{"altitude", "latlng"}, nil, AltitudeGPS},
+		{"Mystery Device", []string{"altitude"}, nil, AltitudeUnknown},
+		{"Garmin Edge 840", []string{"latlng"}, nil, AltitudeNone},
+		// App hints win over barometric brand hints
+		{"Polar Beat iPhone", []string{"altitude"}, nil, AltitudeGPS},
+		// The samples decide when the device name doesn't
+		{"Mystery Device", []string{"altitude"}, smooth, AltitudeBarometric},
+		{"Mystery Device", []string{"altitude"}, noisy, AltitudeGPS},
+		{"Garmin Edge 840", []string{"altitude"}, noisy, AltitudeGPS},
+	}
+
+	for _, tt := range tests {
+		detail := &ActivityDetail{
+			Activity:    Activity{DeviceName: tt.device},
+			StreamTypes: tt.streams,
+			Altitude:    tt.altitude,
+		}
+		if got := s.ClassifyAltitude(detail); got != tt.want {
+			t.Errorf("ClassifyAltitude(%q, %v, %d samples) = %q; want %q", tt.device, tt.streams, len(tt.altitude), got, tt.want)
+		}
+	}
+
+	// Configured list replaces the defaults
+	s = NewScoringEngine(&Config{BarometricDevices: []string{"Mystery"}})
+	detail := &ActivityDetail{Activity: Activity{DeviceName: "Mystery Device"}, StreamTypes: []string{"altitude"}}
+	if got := s.ClassifyAltitude(detail); got != AltitudeBarometric {
+		t.Errorf("ClassifyAltitude with configured devices = %q; want %q", got, AltitudeBarometric)
+	}
+}
+
+func TestLowBatteryPenalty(t *testing.T) {
+	s := NewScoringEngine(&Config{Weights: Weights{LowBattery: 3}})
+
+	tests := []struct {
+		battery string
+		want    float64
+	}{
+		{"good", 0},
+		{"LOW", -3},
+		{"critical", -6},
+	}
+
+	for _, tt := range tests {
+		detail := &ActivityDetail{Activity: Activity{PowerMeterBattery: tt.battery}}
+		if got := s.Score(detail).Breakdown["Power Meter Battery"]; got != tt.want {
+			t.Errorf("battery %q penalty = %.1f; want %.1f", tt.battery, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SensorStats summarizes a single power meter across the scanned window
+type SensorStats struct {
+	Serial       string
+	PowerMeter   string
+	Battery      string // Last seen battery state
+	LastSeen     time.Time
+	Activities   int // Activities recorded with this meter
+	Contests     int // Duplicate groups this meter took part in
+	ContestsLost int // Duplicate groups where this meter's recording was not the winner
+}
+
+// sensorKey identifies a power meter by serial, falling back to its name
+func sensorKey(a *Activity) string {
+	if a.PowerMeterSerial != "" {
+		return a.PowerMeterSerial
+	}
+	return a.PowerMeter
+}
+
+// BuildSensorReport aggregates power meter battery states from all activities
+// and win/loss counts from the ranked duplicate groups.
+func BuildSensorReport(activities []Activity, contests [][]evaluatedActivity) []SensorStats {
+	stats := make(map[string]*SensorStats)
+
+	get := func(a *Activity) *SensorStats {
+		key := sensorKey(a)
+		if key == "" {
+			return nil
+		}
+		st, ok := stats[key]
+		if !ok {
+			st = &SensorStats{Serial: a.PowerMeterSerial, PowerMeter: a.PowerMeter}
+			stats[key] = st
+		}
+		return st
+	}
+
+	for i := range activities {
+		a := &activities[i]
+		st := get(a)
+		if st == nil {
+			continue
+		}
+		st.Activities++
+		if !a.StartDateLocal.Time.Before(st.LastSeen) {
+			st.LastSeen = a.StartDateLocal.Time
+			if a.PowerMeterBattery != "" {
+				st.Battery = a.PowerMeterBattery
+			}
+		}
+		if st.PowerMeter == "" {
+			st.PowerMeter = a.PowerMeter
+		}
+	}
+
+	for _, ranked := range contests {
+		for i := range ranked {
+			st := get(&ranked[i].Detail.Activity)
+			if st == nil {
+				continue
+			}
+			st.Contests++
+			if i > 0 {
+				st.ContestsLost++
+			}
+		}
+	}
+
+	var report []SensorStats
+	for _, st := range stats {
+		report = append(report, *st)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].ContestsLost != report[j].ContestsLost {
+			return report[i].ContestsLost > report[j].ContestsLost
+		}
+		return report[i].Serial+report[i].PowerMeter < report[j].Serial+report[j].PowerMeter
+	})
+
+	return report
+}
+
+// runSensorsReport prints every power meter seen in the window with its last
+// battery state and how often it lost a duplicate contest.
+func runSensorsReport(client *IntervalsClient, scoring *ScoringEngine, oldest, newest time.Time) {
+	fmt.Printf("🔋 Scanning power meters from %s to %s...\n", oldest.Format("2006-01-02"), newest.Format("2006-01-02"))
+
+	activities, err := client.ListActivities(oldest, newest)
+	if err != nil {
+		log.Fatalf("Error fetching activities: %v", err)
+	}
+
+	var contests [][]evaluatedActivity
+	for _, group := range groupActivities(activities) {
+		details := fetchDetails(os.Stdout, client, group)
+		if len(details) <= 1 {
+			continue
+		}
+		contests = append(contests, rankDetails(scoring, details))
+	}
+
+	report := BuildSensorReport(activities, contests)
+	if len(report) == 0 {
+		fmt.Println("No power meters found.")
+		return
+	}
+
+	fmt.Printf("\n%-12s %-24s %-10s %-10s %6s %8s %6s\n", "SERIAL", "POWER METER", "BATTERY", "LAST SEEN", "ACTS", "CONTESTS", "LOST")
+	for _, st := range report {
+		battery := st.Battery
+		if battery == "" {
+			battery = "-"
+		}
+		warning := ""
+		switch strings.ToLower(battery) {
+		case "low", "critical":
+			warning = " ⚠️"
+		}
+		fmt.Printf("%-12s %-24s %-10s %-10s %6d %8d %6d%s\n",
+			valueOr(st.Serial, "-"), valueOr(st.PowerMeter, "-"), battery, st.LastSeen.Format("2006-01-02"),
+			st.Activities, st.Contests, st.ContestsLost, warning)
+	}
+}
+
+func valueOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildSensorReport(t *testing.T) {
+	day := func(d int) IntervalsTime {
+		return IntervalsTime{time.Date(2024, 5, d, 8, 0, 0, 0, time.UTC)}
+	}
+
+	activities := []Activity{
+		{ID: "i1", PowerMeter: "Assioma", PowerMeterSerial: "111", PowerMeterBattery: "ok", StartDateLocal: day(1)},
+		{ID: "i2", PowerMeter: "Assioma", PowerMeterSerial: "111", PowerMeterBattery: "low", StartDateLocal: day(3)},
+		{ID: "i3", PowerMeter: "KICKR", PowerMeterSerial: "222", PowerMeterBattery: "good", StartDateLocal: day(3)},
+		{ID: "i4", Name: "No power"},
+	}
+	contests := [][]evaluatedActivity{
+		{
+			{Detail: ActivityDetail{Activity: activities[2]}},
+			{Detail: ActivityDetail{Activity: activities[1]}},
+		},
+	}
+
+	report := BuildSensorReport(activities, contests)
+	if len(report) != 2 {
+		t.Fatalf("expected 2 sensors, got %d", len(report))
+	}
+
+	pedals := report[0]
+	if pedals.Serial != "111" || pedals.Battery != "low" || pedals.Activities != 2 || pedals.Contests != 1 || pedals.ContestsLost != 1 {
+		t.Errorf("unexpected pedal stats: %+v", pedals)
+	}
+	trainer := report[1]
+	if trainer.Serial != "222" || trainer.Contests != 1 || trainer.ContestsLost != 0 {
+		t.Errorf("unexpected trainer stats: %+v", trainer)
+	}
+}
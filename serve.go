@@ -0,0 +1,493 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// previewPoints caps the number of points drawn in a preview
+const previewPoints = 400
+
+// reviewServer is the local web UI for reviewing duplicate groups
+type reviewServer struct {
+	run            *Run
+	oldest, newest time.Time
+	token          string // Guards form posts against cross-site requests
+
+	mu       sync.Mutex
+	groups   []*ReviewGroup
+	previews map[string][]byte
+}
+
+// fieldOption is one member offering a value for an adoptable field
+type fieldOption struct {
+	ID       string
+	Value    string
+	Selected bool
+}
+
+// fieldChoice lets the reviewer pick where a winner field comes from
+type fieldChoice struct {
+	Field   string
+	Current string
+	Options []fieldOption
+}
+
+// reviewCard is a member card with its form state
+type reviewCard struct {
+	htmlMember
+	Delete  bool
+	Preview bool
+}
+
+// runServe scans the window once and serves the review UI until interrupted
+func runServe(run *Run, oldest, newest time.Time, addr string) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		log.Fatalf("Error generating form token: %v", err)
+	}
+	s := &reviewServer{run: run, oldest: oldest, newest: newest, token: hex.EncodeToString(token)}
+	if err := s.scan(); err != nil {
+		log.Fatalf("Error fetching activities: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleIndex)
+	mux.HandleFunc("GET /groups/{key}", s.handleGroup)
+	mux.HandleFunc("POST /groups/{key}/apply", s.handleApply)
+	mux.HandleFunc("POST /groups/{key}/recalculate", s.handleRecalculate)
+	mux.HandleFunc("POST /rescan", s.handleRescan)
+	mux.HandleFunc("GET /preview/{id}", s.handlePreview)
+
+	mode := ""
+	if run.DryRun {
+		mode = " (dry run: changes are only recorded in the audit log)"
+	}
+	fmt.Printf("🌐 Review UI listening on http://%s%s\n", addr, mode)
+	log.Fatal(http.ListenAndServe(addr, mux))
+}
+
+// scan fetches and scores the duplicate groups in the window
+func (s *reviewServer) scan() error {
+	fmt.Printf("🔍 Scanning for duplicates from %s to %s...\n", s.oldest.Format("2006-01-02"), s.newest.Format("2006-01-02"))
+	activities, err := s.run.Client.ListActivities(s.oldest, s.newest)
+	if err != nil {
+		return err
+	}
+	groups := s.run.ReviewGroups(activities)
+	fmt.Printf("📋 %d duplicate groups ready for review\n", len(groups))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.groups = groups
+	s.previews = make(map[string][]byte)
+	return nil
+}
+
+func (s *reviewServer) group(key string) *ReviewGroup {
+	for _, g := range s.groups {
+		if g.Key == key {
+			return g
+		}
+	}
+	return nil
+}
+
+func (s *reviewServer) handleIndex(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	type row struct {
+		*ReviewGroup
+		Winner evaluatedActivity
+	}
+	var rows []row
+	for _, g := range s.groups {
+		rows = append(rows, row{g, g.Members[0]})
+	}
+	s.render(w, "index", map[string]interface{}{
+		"Rows": rows,
+	})
+}
+
+func (s *reviewServer) handleGroup(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g := s.group(r.PathValue("key"))
+	if g == nil {
+		http.NotFound(w, r)
+		return
+	}
+	decision := s.run.ProposeDecision(g, r.URL.Query().Get("winner"))
+	s.renderGroup(w, g, decision)
+}
+
+func (s *reviewServer) renderGroup(w http.ResponseWriter, g *ReviewGroup, decision Decision) {
+	report := newHTMLGroup(s.run.DescribeDecision(g, decision))
+
+	var cards []reviewCard
+	for i, c := range report.Cards {
+		detail := &g.Members[i].Detail
+		cards = append(cards, reviewCard{
+			htmlMember: c,
+			Delete:     decision.Delete[c.ID],
+			Preview:    hasStream(detail, "latlng") || hasStream(detail, "watts") || hasStream(detail, "heartrate"),
+		})
+	}
+
+	s.render(w, "group", map[string]interface{}{
+		"Group":  g,
+		"Cards":  cards,
+		"Fields": fieldChoices(g, decision),
+	})
+}
+
+// fieldChoices lists, for each adoptable field, the winner's current value and
+// the other members' values, with the decision's source selected
+func fieldChoices(g *ReviewGroup, d Decision) []fieldChoice {
+	winner := g.Member(d.WinnerID)
+	var choices []fieldChoice
+	for _, field := range adoptableFields {
+		current, _ := fieldValue(&winner.Detail, field)
+		choice := fieldChoice{Field: field, Current: fmt.Sprint(current)}
+		for _, m := range g.Members {
+			if m.Detail.ID == winner.Detail.ID {
+				continue
+			}
+			if v, ok := fieldValue(&m.Detail, field); ok {
+				choice.Options = append(choice.Options, fieldOption{
+					ID:       m.Detail.ID,
+					Value:    fmt.Sprint(v),
+					Selected: d.Sources[field] == m.Detail.ID,
+				})
+			}
+		}
+		if len(choice.Options) > 0 {
+			choices = append(choices, choice)
+		}
+	}
+	return choices
+}
+
+// decisionFromForm builds the reviewer's decision from the submitted form
+func decisionFromForm(g *ReviewGroup, r *http.Request) (Decision, error) {
+	winnerID := r.PostFormValue("winner")
+	if g.Member(winnerID) == nil {
+		return Decision{}, fmt.Errorf("unknown winner %q", winnerID)
+	}
+
+	d := Decision{
+		WinnerID: winnerID,
+		Updates:  make(map[string]interface{}),
+		Sources:  make(map[string]string),
+		Delete:   make(map[string]bool),
+	}
+	for _, field := range adoptableFields {
+		source := r.PostFormValue("adopt_" + field)
+		if source == "" || source == winnerID {
+			continue
+		}
+		m := g.Member(source)
+		if m == nil {
+			return Decision{}, fmt.Errorf("unknown source %q for %s", source, field)
+		}
+		if v, ok := fieldValue(&m.Detail, field); ok {
+			d.Updates[field] = v
+			d.Sources[field] = source
+		}
+	}
+	for _, id := range r.PostForm["delete"] {
+		if g.Member(id) == nil {
+			return Decision{}, fmt.Errorf("unknown activity %q", id)
+		}
+		if id != winnerID {
+			d.Delete[id] = true
+		}
+	}
+	return d, nil
+}
+
+func (s *reviewServer) handleApply(w http.ResponseWriter, r *http.Request) {
+	if r.PostFormValue("token") != s.token {
+		http.Error(w, "invalid form token", http.StatusForbidden)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g := s.group(r.PathValue("key"))
+	if g == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if g.Applied {
+		http.Error(w, "group has already been applied", http.StatusConflict)
+		return
+	}
+	decision, err := decisionFromForm(g, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fmt.Printf("✅ Applying group %s (winner %s)\n", g.Key, decision.WinnerID)
+	s.run.ApplyDecision(g, decision)
+	if err := recordReview(s.run, "serve", []*GroupReport{s.run.DescribeDecision(g, decision)}); err != nil {
+		fmt.Printf("⚠️  Failed to record run history: %v\n", err)
+	}
+	http.Redirect(w, r, "/groups/"+g.Key+"?winner="+url.QueryEscape(decision.WinnerID), http.StatusSeeOther)
+}
+
+// handleRecalculate redirects to the group page for the selected winner, so the
+// form token never ends up in a URL
+func (s *reviewServer) handleRecalculate(w http.ResponseWriter, r *http.Request) {
+	if r.PostFormValue("token") != s.token {
+		http.Error(w, "invalid form token", http.StatusForbidden)
+		return
+	}
+
+	s.mu.Lock()
+	g := s.group(r.PathValue("key"))
+	s.mu.Unlock()
+	if g == nil {
+		http.NotFound(w, r)
+		return
+	}
+	target := "/groups/" + g.Key
+	if winner := r.PostFormValue("winner"); g.Member(winner) != nil {
+		target += "?winner=" + url.QueryEscape(winner)
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+func (s *reviewServer) handleRescan(w http.ResponseWriter, r *http.Request) {
+	if r.PostFormValue("token") != s.token {
+		http.Error(w, "invalid form token", http.StatusForbidden)
+		return
+	}
+	if err := s.scan(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (s *reviewServer) handlePreview(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	s.mu.Lock()
+	svg, ok := s.previews[id]
+	s.mu.Unlock()
+
+	if !ok {
+		streams, err := s.run.Client.GetStreams(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		svg = PreviewSVG(streams)
+		s.mu.Lock()
+		s.previews[id] = svg
+		s.mu.Unlock()
+	}
+	if svg == nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Write(svg)
+}
+
+// render executes a page template, adding the fields every page uses
+func (s *reviewServer) render(w http.ResponseWriter, name string, data map[string]interface{}) {
+	data["AthleteID"] = s.run.Config.AthleteID
+	data["Oldest"], data["Newest"] = s.oldest, s.newest
+	data["DryRun"] = s.run.DryRun
+	data["Token"] = s.token
+
+	var buf bytes.Buffer
+	if err := reviewTemplates.ExecuteTemplate(&buf, name, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// PreviewSVG draws the GPS track of an activity, or its power or heart rate
+// trace when it has no GPS. It returns nil when there is nothing to draw.
+func PreviewSVG(streams []Stream) []byte {
+	byType := make(map[string]Stream)
+	for _, st := range streams {
+		byType[st.Type] = st
+	}
+
+	var xs, ys []float64
+	label := ""
+	if st, ok := byType["latlng"]; ok {
+		for i := range st.Data {
+			if i >= len(st.Data2) || (st.Data[i] == 0 && st.Data2[i] == 0) {
+				continue
+			}
+			// Equirectangular projection, flipped so north is up
+			xs = append(xs, st.Data2[i]*math.Cos(st.Data[i]*math.Pi/180))
+			ys = append(ys, -st.Data[i])
+		}
+		label = "GPS track"
+	}
+	if len(xs) < 2 {
+		xs, ys = nil, nil
+		for _, t := range []string{"watts", "heartrate"} {
+			st, ok := byType[t]
+			if !ok || len(st.Data) < 2 {
+				continue
+			}
+			for i, v := range st.Data {
+				xs = append(xs, float64(i))
+				ys = append(ys, -v)
+			}
+			label = t
+			break
+		}
+	}
+	if len(xs) < 2 {
+		return nil
+	}
+
+	minX, maxX, minY, maxY := xs[0], xs[0], ys[0], ys[0]
+	for i := range xs {
+		minX, maxX = math.Min(minX, xs[i]), math.Max(maxX, xs[i])
+		minY, maxY = math.Min(minY, ys[i]), math.Max(maxY, ys[i])
+	}
+	const width, height, pad = 240.0, 140.0, 5.0
+	scaleX := (width - 2*pad) / math.Max(maxX-minX, 1e-9)
+	scaleY := (height - 2*pad) / math.Max(maxY-minY, 1e-9)
+	if label == "GPS track" {
+		// Keep the map's aspect ratio
+		scaleX = math.Min(scaleX, scaleY)
+		scaleY = scaleX
+	}
+
+	step := len(xs)/previewPoints + 1
+	var points strings.Builder
+	for i := 0; i < len(xs); i += step {
+		fmt.Fprintf(&points, "%.1f,%.1f ", pad+(xs[i]-minX)*scaleX, pad+(ys[i]-minY)*scaleY)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f">`, width, height, width, height)
+	fmt.Fprintf(&buf, `<title>%s</title><rect width="100%%" height="100%%" fill="#fafafa"/>`, label)
+	fmt.Fprintf(&buf, `<polyline fill="none" stroke="#4a90d9" stroke-width="1.5" points="%s"/></svg>`, strings.TrimSpace(points.String()))
+	return buf.Bytes()
+}
+
+var reviewTemplates = template.Must(template.New("review").Funcs(htmlFuncs).Parse(`
+{{define "head"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.}}</title>
+<style>
+{{style}}
+.review td, .review th { padding: 0.3em 1em 0.3em 0; text-align: left; }
+.controls { margin: 0.5em 0; font-size: 0.9em; }
+.preview { display: block; margin: 0.4em 0; max-width: 100%; }
+.fields td { padding: 0.2em 1em 0.2em 0; vertical-align: top; }
+.applied { color: #2e9e44; font-weight: bold; }
+button { padding: 0.4em 1em; }
+</style>
+</head>
+<body>
+{{end}}
+
+{{define "index"}}{{template "head" "Duplicate review"}}
+<h1>Duplicate review</h1>
+<div class="meta">Athlete {{.AthleteID}} · {{date .Oldest}} to {{date .Newest}}{{if .DryRun}} · dry run{{end}}</div>
+<form method="post" action="/rescan"><input type="hidden" name="token" value="{{.Token}}"><button>Rescan</button></form>
+{{if .Rows}}
+<table class="review">
+<tr><th>Start</th><th>Recordings</th><th>Suggested winner</th><th>Status</th></tr>
+{{range .Rows}}<tr>
+<td><a href="/groups/{{.Key}}">{{datetime .Start}}</a></td>
+<td>{{len .Members}}</td>
+<td>{{.Winner.Detail.Name}} ({{.Winner.Detail.DeviceName}})</td>
+<td>{{if .Applied}}<span class="applied">applied</span>{{else}}pending{{end}}</td>
+</tr>{{end}}
+</table>
+{{else}}<p>No duplicate groups found.</p>{{end}}
+</body>
+</html>
+{{end}}
+
+{{define "group"}}{{template "head" "Duplicate group"}}
+<p><a href="/">← All groups</a></p>
+<div class="group">
+<h2>{{datetime .Group.Start}} · {{len .Group.Members}} recordings{{if .Group.Applied}} · <span class="applied">applied</span>{{end}}</h2>
+<form method="post" action="/groups/{{.Group.Key}}/apply">
+<input type="hidden" name="token" value="{{.Token}}">
+<div class="cards">
+{{range .Cards}}
+<div class="card{{if .Winner}} winner{{end}}">
+<h3>#{{.Rank}} {{.Name}} <span class="plan plan-{{.Plan}}">{{.Plan}}</span></h3>
+<div class="controls">
+<label><input type="radio" name="winner" value="{{.ID}}"{{if .Winner}} checked{{end}}> Winner</label>
+{{if not .Winner}}<label><input type="checkbox" name="delete" value="{{.ID}}"{{if .Delete}} checked{{end}}{{if .Protected}} disabled{{end}}> Delete</label>{{end}}
+</div>
+{{if .Preview}}<img class="preview" src="/preview/{{.ID}}" alt="" loading="lazy">{{end}}
+<dl>
+<dt>ID</dt><dd>{{.ID}}</dd>
+<dt>Type</dt><dd>{{.Type}}</dd>
+<dt>Device</dt><dd>{{or .Device "—"}}</dd>
+<dt>Uploader</dt><dd>{{or .Uploader "—"}}</dd>
+<dt>Distance</dt><dd>{{distance .Distance}}{{if not .Winner}} (Δ {{percent .DistDiff}}){{end}}</dd>
+<dt>Moving time</dt><dd>{{duration .MovingTime}}{{if not .Winner}} (Δ {{percent .TimeDiff}}){{end}}</dd>
+<dt>Score</dt><dd><strong>{{score .Score}}</strong></dd>
+</dl>
+{{if .DistMismatch}}<div class="warning">⚠ Distance mismatch</div>{{end}}
+{{if .TimeMismatch}}<div class="warning">⚠ Moving time mismatch</div>{{end}}
+{{if .Protected}}<div class="warning">🛡 Protected: {{.Protected}}</div>{{end}}
+<div class="bars">
+{{range .Bars}}<div class="bar"><span>{{.Name}}</span><div class="track"><div class="fill{{if .Penalty}} penalty{{end}}" style="width: {{printf "%.0f" .Width}}%"></div></div><span class="value">{{score .Value}}</span></div>
+{{end}}
+</div>
+</div>
+{{end}}
+</div>
+{{if .Fields}}
+<h3>Winner fields</h3>
+<table class="fields">
+{{range .Fields}}<tr><td>{{.Field}}</td><td><select name="adopt_{{.Field}}">
+<option value="">Keep: {{or .Current "(empty)"}}</option>
+{{range .Options}}<option value="{{.ID}}"{{if .Selected}} selected{{end}}>From {{.ID}}: {{.Value}}</option>
+{{end}}</select></td></tr>
+{{end}}
+</table>
+{{end}}
+<p>
+<button formaction="/groups/{{.Group.Key}}/recalculate">Recalculate for selected winner</button>
+{{if not .Group.Applied}}<button>Apply</button>{{end}}
+</p>
+</form>
+{{if .Group.Actions}}
+<ul class="actions">
+{{range .Group.Actions}}<li class="status-{{.Status}}">{{.Action}} {{.ActivityID}}: {{.Status}}{{if .Error}} ({{.Error}}){{end}}</li>
+{{end}}
+</ul>
+{{end}}
+</div>
+</body>
+</html>
+{{end}}
+`))
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestReviewServer(t *testing.T, calls *[]string) (*reviewServer, func()) {
+	t.Helper()
+	api := newFakeIntervals(t, calls)
+	run := newTestRun(t, api, false)
+	s := &reviewServer{run: run, token: "secret"}
+	if err := s.scan(); err != nil {
+		t.Fatalf("scan error: %v", err)
+	}
+	if len(s.groups) != 1 {
+		t.Fatalf("got %d groups; want 1", len(s.groups))
+	}
+	return s, api.Close
+}
+
+func TestReviewServerApply(t *testing.T) {
+	var calls []string
+	s, closeAPI := newTestReviewServer(t, &calls)
+	defer closeAPI()
+	key := s.groups[0].Key
+
+	// Pick the lower-ranked phone recording as the winner and give it the
+	// head unit's descriptive name
+	form := url.Values{
+		"token":      {"secret"},
+		"winner":     {"i2"},
+		"adopt_name": {"i1"},
+		"delete":     {"i1"},
+	}
+	req := httptest.NewRequest("POST", "/groups/"+key+"/apply", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetPathValue("key", key)
+	rec := httptest.NewRecorder()
+	s.handleApply(rec, req)
+
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("status = %d; body %s", rec.Code, rec.Body.String())
+	}
+	want := []string{`PUT i2 {"name":"Ellisville - Weldon"}`, "DELETE i1"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v; want %v", calls, want)
+	}
+	if !s.groups[0].Applied {
+		t.Error("group not marked as applied")
+	}
+	records, err := NewHistoryLog(s.run.Config.HistoryFile()).Query("i0", time.Time{}, time.Now())
+	if err != nil || len(records) != 1 || records[0].Mode != "serve" || records[0].Summary.Deleted != 1 || records[0].Groups[0].DecidedBy != "manual" {
+		t.Errorf("history = %+v, %v; want the applied group recorded", records, err)
+	}
+
+	// A second submission is rejected
+	req = httptest.NewRequest("POST", "/groups/"+key+"/apply", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetPathValue("key", key)
+	rec = httptest.NewRecorder()
+	s.handleApply(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("second apply status = %d; want %d", rec.Code, http.StatusConflict)
+	}
+}
+
+func TestReviewServerRejectsBadToken(t *testing.T) {
+	var calls []string
+	s, closeAPI := newTestReviewServer(t, &calls)
+	defer closeAPI()
+	key := s.groups[0].Key
+
+	form := url.Values{"token": {"wrong"}, "winner": {"i1"}, "delete": {"i2"}}
+	req := httptest.NewRequest("POST", "/groups/"+key+"/apply", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetPathValue("key", key)
+	rec := httptest.NewRecorder()
+	s.handleApply(rec, req)
+
+	if rec.Code != http.StatusForbidden || len(calls) != 0 {
+		t.Errorf("status = %d, calls = %v; want 403 and no calls", rec.Code, calls)
+	}
+}
+
+func TestReviewServerRecalculate(t *testing.T) {
+	var calls []string
+	s, closeAPI := newTestReviewServer(t, &calls)
+	defer closeAPI()
+	key := s.groups[0].Key
+
+	form := url.Values{"token": {"secret"}, "winner": {"i2"}, "delete": {"i1"}}
+	req := httptest.NewRequest("POST", "/groups/"+key+"/recalculate", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetPathValue("key", key)
+	rec := httptest.NewRecorder()
+	s.handleRecalculate(rec, req)
+
+	location := rec.Header().Get("Location")
+	if rec.Code != http.StatusSeeOther || location != "/groups/"+key+"?winner=i2" {
+		t.Errorf("status = %d, location = %q; want a redirect to the group for i2", rec.Code, location)
+	}
+	if strings.Contains(location, "secret") || len(calls) != 0 {
+		t.Errorf("location = %q, calls = %v; want no token and no changes", location, calls)
+	}
+}
+
+func TestReviewServerGroupPage(t *testing.T) {
+	var calls []string
+	s, closeAPI := newTestReviewServer(t, &calls)
+	defer closeAPI()
+	key := s.groups[0].Key
+
+	req := httptest.NewRequest("GET", "/groups/"+key+"?winner=i2", nil)
+	req.SetPathValue("key", key)
+	rec := httptest.NewRecorder()
+	s.handleGroup(rec, req)
+
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; body %s", rec.Code, body)
+	}
+	for _, want := range []string{
+		`<input type="radio" name="winner" value="i2" checked>`,
+		`<input type="checkbox" name="delete" value="i1" checked>`,
+		`<option value="i1" selected>From i1: Ellisville - Weldon</option>`,
+		`/preview/i1`,
+		`formaction="/groups/` + key + `/recalculate"`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page missing %q", want)
+		}
+	}
+}
+
+func TestPreviewSVG(t *testing.T) {
+	if PreviewSVG(nil) != nil {
+		t.Error("expected no preview without streams")
+	}
+
+	track := PreviewSVG([]Stream{{Type: "latlng", Data: []float64{51.0, 51.1, 0}, Data2: []float64{-1.0, -0.9, 0}}})
+	if !strings.Contains(string(track), "<title>GPS track</title>") || strings.Count(string(track), ",") != 2 {
+		t.Errorf("unexpected GPS preview: %s", track)
+	}
+
+	power := PreviewSVG([]Stream{{Type: "watts", Data: []float64{100, 200, 150}}})
+	if !strings.Contains(string(power), "<title>watts</title>") {
+		t.Errorf("unexpected power preview: %s", power)
+	}
+}
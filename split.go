@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultSplitMaxGap = 10 * time.Minute
+	// splitTolerance allows for small overlaps between fragments and slack when
+	// deciding whether a recording covers all fragments
+	splitTolerance = 2 * time.Minute
+)
+
+// SplitConfig controls detection and handling of recordings split into fragments
+// (e.g. after a watch crash)
+type SplitConfig struct {
+	MaxGapMinutes   int  `yaml:"max_gap_minutes"`  // Largest gap between fragments (default 10)
+	DeleteFragments bool `yaml:"delete_fragments"` // Delete fragments when a complete recording exists
+	Stitch          bool `yaml:"stitch"`           // Stitch fragments into one upload when no complete recording exists
+}
+
+// MaxGap returns the configured gap between fragments, or the default
+func (c SplitConfig) MaxGap() time.Duration {
+	if c.MaxGapMinutes > 0 {
+		return time.Duration(c.MaxGapMinutes) * time.Minute
+	}
+	return defaultSplitMaxGap
+}
+
+// SplitCandidate is a chain of sequential fragments, optionally with a single
+// recording that covers all of them
+type SplitCandidate struct {
+	Fragments []Activity
+	Complete  *Activity
+}
+
+// activityStart prefers the UTC start time, falling back to local time
+func activityStart(a *Activity) time.Time {
+	if !a.StartDate.IsZero() {
+		return a.StartDate.Time
+	}
+	return a.StartDateLocal.Time
+}
+
+func activityEnd(a *Activity) time.Time {
+	seconds := a.ElapsedTime
+	if seconds == 0 {
+		seconds = a.MovingTime
+	}
+	return activityStart(a).Add(time.Duration(seconds) * time.Second)
+}
+
+// covers reports whether c starts with the first fragment and ends no earlier
+// than the last one, within splitTolerance
+func covers(c, first, last *Activity) bool {
+	startDiff := activityStart(c).Sub(activityStart(first))
+	if startDiff < -splitTolerance || startDiff > splitTolerance {
+		return false
+	}
+	return !activityEnd(c).Before(activityEnd(last).Add(-splitTolerance))
+}
+
+// coversFragment reports whether c could be the complete recording of the
+// single fragment f: it covers f and is longer
+func coversFragment(c, f *Activity) bool {
+	return covers(c, f, f) && activityEnd(c).Sub(activityStart(c)) > activityEnd(f).Sub(activityStart(f))
+}
+
+// DetectSplits finds chains of same-type activities from the same device where
+// each one starts shortly after the previous one ended, together with a longer
+// recording spanning the whole chain. Chains nothing covers look just like
+// consecutive legitimate activities, so they are only returned when stitching is
+// enabled. hints maps activities already known to be fragments (losers kept
+// under the split mismatch policy) to the recording that covers them.
+func DetectSplits(activities []Activity, config SplitConfig, hints map[string]string) []SplitCandidate {
+	maxGap := config.MaxGap()
+	sorted := make([]Activity, len(activities))
+	copy(sorted, activities)
+	sort.Slice(sorted, func(i, j int) bool {
+		return activityStart(&sorted[i]).Before(activityStart(&sorted[j]))
+	})
+
+	used := make(map[string]bool)
+	var candidates []SplitCandidate
+
+	for i := range sorted {
+		if used[sorted[i].ID] {
+			continue
+		}
+
+		chain := []Activity{sorted[i]}
+		last := &sorted[i]
+		for j := i + 1; j < len(sorted); j++ {
+			next := &sorted[j]
+			if used[next.ID] || next.Type != last.Type || next.DeviceName != last.DeviceName {
+				continue
+			}
+			gap := activityStart(next).Sub(activityEnd(last))
+			if gap > maxGap {
+				break
+			}
+			if gap >= -splitTolerance {
+				chain = append(chain, *next)
+				last = next
+			}
+		}
+
+		if len(chain) < 2 {
+			continue
+		}
+		for _, f := range chain {
+			used[f.ID] = true
+		}
+
+		candidate := SplitCandidate{Fragments: chain}
+		first := &chain[0]
+		for k := range sorted {
+			c := &sorted[k]
+			if used[c.ID] || c.Type != first.Type || !covers(c, first, last) {
+				continue
+			}
+			if candidate.Complete == nil || c.ElapsedTime > candidate.Complete.ElapsedTime {
+				candidate.Complete = c
+			}
+		}
+		// Prefer the winner a fragment lost to, if it spans the whole chain too
+		for _, f := range chain {
+			if c := findActivity(sorted, hints[f.ID]); c != nil && !used[c.ID] && covers(c, first, last) {
+				candidate.Complete = c
+			}
+		}
+		if candidate.Complete == nil && !config.Stitch {
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	// A hinted fragment that didn't chain with another is still covered by its
+	// winner, as long as the winner really spans it
+	for i := range sorted {
+		f := &sorted[i]
+		if used[f.ID] {
+			continue
+		}
+		if c := findActivity(sorted, hints[f.ID]); c != nil && !used[c.ID] && coversFragment(c, f) {
+			used[f.ID] = true
+			candidates = append(candidates, SplitCandidate{Fragments: []Activity{*f}, Complete: c})
+		}
+	}
+
+	return candidates
+}
+
+func findActivity(activities []Activity, id string) *Activity {
+	if id == "" {
+		return nil
+	}
+	for i := range activities {
+		if activities[i].ID == id {
+			return &activities[i]
+		}
+	}
+	return nil
+}
+
+// handleSplits reports split recordings and, depending on config, deletes the
+// fragments or stitches them into a single uploaded activity. Activities already
+// deleted in this run are neither fragments nor complete recordings.
+func (r *Run) handleSplits(activities []Activity) {
+	var remaining []Activity
+	for _, a := range activities {
+		if !r.Deleted[a.ID] {
+			remaining = append(remaining, a)
+		}
+	}
+
+	for _, candidate := range DetectSplits(remaining, r.Config.Splits, r.SplitHints) {
+		report := &SplitReport{
+			AthleteID:  r.Config.AthleteID,
+			Start:      activityStart(&candidate.Fragments[0]),
+			Fragments:  activityIDs(candidate.Fragments),
+			CompleteID: winnerID(candidate),
+		}
+		r.processSplit(candidate, report)
+		r.Report.Splits = append(r.Report.Splits, report)
+		r.Output.Split(report)
+	}
+}
+
+// processSplit deletes or stitches the fragments of one split recording
+func (r *Run) processSplit(candidate SplitCandidate, report *SplitReport) {
+	fragments := candidate.Fragments
+	first := fragments[0]
+	fmt.Fprintf(r.Progress, "\n🧩 Found %d fragments of a split recording starting around: %s\n", len(candidate.Fragments), first.StartDateLocal.Time.Format("2006-01-02 15:04:05"))
+	for _, f := range candidate.Fragments {
+		fmt.Fprintf(r.Progress, "    - [%s] %s (%s - %s, %s)\n", f.ID, f.Name,
+			activityStart(&f).Local().Format("15:04:05"), activityEnd(&f).Local().Format("15:04:05"), formatDistance(f.Distance))
+	}
+
+	if candidate.Complete != nil {
+		c := candidate.Complete
+		fmt.Fprintf(r.Progress, "  🏆 Complete recording: (ID: %s) - %s (%s, %s)\n", c.ID, c.Name, formatDistance(c.Distance), formatDuration(c.MovingTime))
+		if !r.Config.Splits.DeleteFragments {
+			fmt.Fprintf(r.Progress, "    ⏭️  Keeping fragments (enable splits.delete_fragments to remove them)\n")
+			return
+		}
+		r.deleteFragments(fragments, winnerID(candidate), r.DryRun, r.Interactive, &report.Actions)
+		return
+	}
+
+	protected := ""
+	var names []string
+	for _, f := range fragments {
+		names = append(names, f.Name)
+		if reason := r.Protector.Check(&f); reason != "" && protected == "" {
+			protected = fmt.Sprintf("%s is protected (%s)", f.ID, reason)
+		}
+	}
+	if protected != "" {
+		fmt.Fprintf(r.Progress, "    🛡️  Flagged only, not stitching: %s\n", protected)
+		return
+	}
+	name := r.Scoring.RankCandidateNames(names, first.Type)
+	if name == "" {
+		name = first.Name
+	}
+
+	if r.Interactive && !confirm(r.Progress, fmt.Sprintf("    Stitch %d fragments into \"%s\"?", len(fragments), name), false) {
+		fmt.Fprintf(r.Progress, "    ⏭️  Skipped stitching\n")
+		report.Actions = append(report.Actions, ActionReport{Action: AuditUpload, Status: StatusDeclined})
+		return
+	}
+	entry := AuditEntry{Action: AuditUpload, Before: fragments, After: map[string]interface{}{"name": name}, DryRun: r.DryRun}
+	if r.DryRun {
+		fmt.Fprintf(r.Progress, "    [DRY RUN] Would stitch %d fragments into \"%s\" and delete the fragments\n", len(fragments), name)
+		r.recordAction(&report.Actions, entry, nil)
+		return
+	}
+
+	fmt.Fprintf(r.Progress, "    Stitching %d fragments...\n", len(fragments))
+	id, err := stitchFragments(r.Client, first.Type, name, fragments)
+	entry.ActivityID = id
+	r.recordAction(&report.Actions, entry, err)
+	if err != nil {
+		fmt.Fprintf(r.Progress, "    ❌ Error stitching fragments: %v\n", err)
+		return
+	}
+	fmt.Fprintf(r.Progress, "    ✅ Uploaded stitched activity %s\n", id)
+	r.deleteFragments(fragments, id, false, r.Interactive, &report.Actions)
+}
+
+// winnerID is the complete recording that replaces the fragments, if any
+func winnerID(candidate SplitCandidate) string {
+	if candidate.Complete != nil {
+		return candidate.Complete.ID
+	}
+	return ""
+}
+
+func (r *Run) deleteFragments(fragments []Activity, winner string, dryRun, interactive bool, actions *[]ActionReport) {
+	for _, f := range fragments {
+		if reason := r.Protector.Check(&f); reason != "" {
+			fmt.Fprintf(r.Progress, "    🛡️  Flagged only, not deleting fragment %s: %s\n", f.ID, reason)
+			continue
+		}
+		if interactive && !confirm(r.Progress, fmt.Sprintf("    Confirm deletion of fragment %s?", f.ID), false) {
+			fmt.Fprintf(r.Progress, "    ⏭️  Skipped deletion of %s\n", f.ID)
+			*actions = append(*actions, ActionReport{ActivityID: f.ID, Action: AuditDelete, Status: StatusDeclined})
+			continue
+		}
+		entry := AuditEntry{ActivityID: f.ID, Action: AuditDelete, Before: f, WinnerID: winner, DryRun: dryRun}
+		if dryRun {
+			fmt.Fprintf(r.Progress, "    [DRY RUN] Would delete fragment %s\n", f.ID)
+			r.recordAction(actions, entry, nil)
+			continue
+		}
+		fmt.Fprintf(r.Progress, "    Deleting fragment %s...\n", f.ID)
+		err := r.Client.DeleteActivity(f.ID)
+		r.recordAction(actions, entry, err)
+		if err != nil {
+			fmt.Fprintf(r.Progress, "    ❌ Error deleting %s: %v\n", f.ID, err)
+			continue
+		}
+		r.Deleted[f.ID] = true
+		fmt.Fprintf(r.Progress, "    ✅ Deleted %s\n", f.ID)
+	}
+}
+
+// stitchFragments downloads each fragment's streams, merges them into a TCX file
+// and uploads it, returning the new activity ID
+func stitchFragments(client *IntervalsClient, activityType, name string, fragments []Activity) (string, error) {
+	var parts []StitchPart
+	for _, f := range fragments {
+		streams, err := client.GetStreams(f.ID)
+		if err != nil {
+			return "", fmt.Errorf("fetching streams for %s: %w", f.ID, err)
+		}
+		parts = append(parts, StitchPart{Start: activityStart(&f), Streams: streams})
+	}
+
+	data, err := BuildTCX(activityType, parts)
+	if err != nil {
+		return "", err
+	}
+
+	filename := fmt.Sprintf("stitched-%s.tcx", strings.ToLower(fragments[0].ID))
+	return client.UploadActivity(filename, data, name)
+}
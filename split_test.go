@@ -0,0 +1,138 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectSplits(t *testing.T) {
+	base := time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC)
+	at := func(minutes int) IntervalsTime {
+		return IntervalsTime{base.Add(time.Duration(minutes) * time.Minute)}
+	}
+
+	activities := []Activity{
+		{ID: "head", Type: "Ride", StartDate: at(0), ElapsedTime: 90 * 60},
+		{ID: "frag1", Type: "Ride", StartDate: at(0), ElapsedTime: 40 * 60},
+		{ID: "frag2", Type: "Ride", StartDate: at(41), ElapsedTime: 49 * 60},
+		{ID: "walk", Type: "Walk", StartDate: at(95), ElapsedTime: 10 * 60},
+		{ID: "later", Type: "Ride", StartDate: at(300), ElapsedTime: 60 * 60},
+	}
+
+	splits := DetectSplits(activities, SplitConfig{}, nil)
+	if len(splits) != 1 {
+		t.Fatalf("expected 1 split candidate, got %d", len(splits))
+	}
+	got := splits[0]
+	if len(got.Fragments) != 2 || got.Fragments[0].ID != "frag1" || got.Fragments[1].ID != "frag2" {
+		t.Errorf("unexpected fragments: %+v", got.Fragments)
+	}
+	if got.Complete == nil || got.Complete.ID != "head" {
+		t.Errorf("expected head unit as complete recording, got %+v", got.Complete)
+	}
+
+	// Without the head unit nothing shows the fragments belong together, unless
+	// stitching asks for uncovered chains
+	if splits = DetectSplits(activities[1:], SplitConfig{}, nil); len(splits) != 0 {
+		t.Errorf("expected no candidate without a complete recording, got %+v", splits)
+	}
+	splits = DetectSplits(activities[1:], SplitConfig{Stitch: true}, nil)
+	if len(splits) != 1 || splits[0].Complete != nil {
+		t.Errorf("expected an uncovered split candidate, got %+v", splits)
+	}
+
+	// A hinted fragment is covered by its winner even without a second fragment
+	splits = DetectSplits(activities[:2], SplitConfig{}, map[string]string{"frag1": "head"})
+	if len(splits) != 1 || len(splits[0].Fragments) != 1 || splits[0].Fragments[0].ID != "frag1" || splits[0].Complete == nil || splits[0].Complete.ID != "head" {
+		t.Errorf("expected the hinted fragment covered by its winner, got %+v", splits)
+	}
+
+	// A hint never replaces the complete recording with one that misses the chain
+	splits = DetectSplits(activities, SplitConfig{}, map[string]string{"frag2": "later"})
+	if len(splits) != 1 || splits[0].Complete == nil || splits[0].Complete.ID != "head" {
+		t.Errorf("expected head unit to stay the complete recording, got %+v", splits)
+	}
+
+	// Hints whose winner doesn't span the fragment are dropped
+	for fragment, winner := range map[string]string{"head": "frag1", "walk": "later"} {
+		if splits = DetectSplits(activities, SplitConfig{}, map[string]string{fragment: winner}); len(splits) != 1 || len(splits[0].Fragments) != 2 {
+			t.Errorf("hint %s -> %s: expected only the frag1/frag2 chain, got %+v", fragment, winner, splits)
+		}
+	}
+}
+
+func TestDetectSplitsConsecutiveActivities(t *testing.T) {
+	base := time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC)
+	at := func(minutes int) IntervalsTime {
+		return IntervalsTime{base.Add(time.Duration(minutes) * time.Minute)}
+	}
+
+	tests := []struct {
+		name       string
+		activities []Activity
+	}{
+		{"commute and ride home", []Activity{
+			{ID: "out", Type: "Ride", DeviceName: "Garmin Edge 840", StartDate: at(0), ElapsedTime: 30 * 60},
+			{ID: "back", Type: "Ride", DeviceName: "Garmin Edge 840", StartDate: at(35), ElapsedTime: 30 * 60},
+		}},
+		{"different devices", []Activity{
+			{ID: "watch", Type: "Ride", DeviceName: "Garmin Fenix 7", StartDate: at(0), ElapsedTime: 40 * 60},
+			{ID: "phone", Type: "Ride", DeviceName: "Phone", StartDate: at(41), ElapsedTime: 49 * 60},
+			{ID: "head", Type: "Ride", DeviceName: "Wahoo ELEMNT", StartDate: at(0), ElapsedTime: 90 * 60},
+		}},
+	}
+
+	for _, tt := range tests {
+		if splits := DetectSplits(tt.activities, SplitConfig{}, nil); len(splits) != 0 {
+			t.Errorf("%s: expected no split candidates, got %+v", tt.name, splits)
+		}
+	}
+}
+
+func TestBuildTCX(t *testing.T) {
+	start := time.Date(2024, 6, 1, 7, 0, 0, 0, time.UTC)
+	parts := []StitchPart{
+		{
+			Start: start,
+			Streams: []Stream{
+				{Type: "time", Data: []float64{0, 1}},
+				{Type: "distance", Data: []float64{0, 10}},
+				{Type: "watts", Data: []float64{200, 210}},
+			},
+		},
+		{
+			Start: start.Add(time.Minute),
+			Streams: []Stream{
+				{Type: "time", Data: []float64{0, 1}},
+				{Type: "distance", Data: []float64{0, 5}},
+				{Type: "latlng", Data: []float64{38.6, 38.7}, Data2: []float64{-90.5, -90.6}},
+			},
+		},
+	}
+
+	data, err := BuildTCX("Ride", parts)
+	if err != nil {
+		t.Fatalf("BuildTCX error: %v", err)
+	}
+	out := string(data)
+
+	for _, want := range []string{
+		`Sport="Biking"`,
+		"<Time>2024-06-01T07:01:01Z</Time>",
+		"<DistanceMeters>15</DistanceMeters>", // Second fragment continues from the first
+		"<ns3:Watts>210</ns3:Watts>",
+		"<LatitudeDegrees>38.7</LatitudeDegrees>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("TCX output missing %q", want)
+		}
+	}
+	if strings.Count(out, "<Lap ") != 2 {
+		t.Errorf("expected one lap per fragment")
+	}
+
+	if _, err := BuildTCX("Ride", []StitchPart{{Start: start}}); err == nil {
+		t.Error("expected error for fragment without time stream")
+	}
+}
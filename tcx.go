@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StitchPart is one fragment of a split recording with its streams
+type StitchPart struct {
+	Start   time.Time
+	Streams []Stream
+}
+
+type tcxDatabase struct {
+	XMLName    xml.Name      `xml:"TrainingCenterDatabase"`
+	Xmlns      string        `xml:"xmlns,attr"`
+	XmlnsNs3   string        `xml:"xmlns:ns3,attr"`
+	Activities []tcxActivity `xml:"Activities>Activity"`
+}
+
+type tcxActivity struct {
+	Sport string   `xml:"Sport,attr"`
+	ID    string   `xml:"Id"`
+	Laps  []tcxLap `xml:"Lap"`
+}
+
+type tcxLap struct {
+	StartTime        string          `xml:"StartTime,attr"`
+	TotalTimeSeconds float64         `xml:"TotalTimeSeconds"`
+	DistanceMeters   float64         `xml:"DistanceMeters"`
+	Intensity        string          `xml:"Intensity"`
+	TriggerMethod    string          `xml:"TriggerMethod"`
+	Trackpoints      []tcxTrackpoint `xml:"Track>Trackpoint"`
+}
+
+type tcxTrackpoint struct {
+	Time           string         `xml:"Time"`
+	Position       *tcxPosition   `xml:"Position,omitempty"`
+	AltitudeMeters *float64       `xml:"AltitudeMeters,omitempty"`
+	DistanceMeters *float64       `xml:"DistanceMeters,omitempty"`
+	HeartRate      *tcxValue      `xml:"HeartRateBpm,omitempty"`
+	Cadence        *int           `xml:"Cadence,omitempty"`
+	Extensions     *tcxExtensions `xml:"Extensions,omitempty"`
+}
+
+type tcxPosition struct {
+	Latitude  float64 `xml:"LatitudeDegrees"`
+	Longitude float64 `xml:"LongitudeDegrees"`
+}
+
+type tcxValue struct {
+	Value int `xml:"Value"`
+}
+
+type tcxExtensions struct {
+	TPX tcxTPX `xml:"ns3:TPX"`
+}
+
+type tcxTPX struct {
+	Watts int `xml:"ns3:Watts"`
+}
+
+// tcxSport maps an Intervals.icu activity type onto a TCX sport
+func tcxSport(activityType string) string {
+	lower := strings.ToLower(activityType)
+	switch {
+	case strings.Contains(lower, "ride"), strings.Contains(lower, "cycl"):
+		return "Biking"
+	case strings.Contains(lower, "run"):
+		return "Running"
+	default:
+		return "Other"
+	}
+}
+
+// BuildTCX stitches the streams of several fragments into a single TCX file,
+// one lap per fragment, with distance continuing across fragments.
+func BuildTCX(activityType string, parts []StitchPart) ([]byte, error) {
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("no fragments to stitch")
+	}
+
+	activity := tcxActivity{
+		Sport: tcxSport(activityType),
+		ID:    parts[0].Start.UTC().Format(time.RFC3339),
+	}
+
+	distanceOffset := 0.0
+	for _, part := range parts {
+		byType := make(map[string]Stream)
+		for _, st := range part.Streams {
+			byType[st.Type] = st
+		}
+		timeStream, ok := byType["time"]
+		if !ok || len(timeStream.Data) == 0 {
+			return nil, fmt.Errorf("fragment starting %s has no time stream", part.Start.Format(time.RFC3339))
+		}
+
+		lap := tcxLap{
+			StartTime:        part.Start.UTC().Format(time.RFC3339),
+			TotalTimeSeconds: timeStream.Data[len(timeStream.Data)-1] - timeStream.Data[0],
+			Intensity:        "Active",
+			TriggerMethod:    "Manual",
+		}
+
+		lastDistance := 0.0
+		for i, offset := range timeStream.Data {
+			tp := tcxTrackpoint{
+				Time: part.Start.Add(time.Duration(offset * float64(time.Second))).UTC().Format(time.RFC3339),
+			}
+			if st, ok := byType["latlng"]; ok && i < len(st.Data) && i < len(st.Data2) && (st.Data[i] != 0 || st.Data2[i] != 0) {
+				tp.Position = &tcxPosition{Latitude: st.Data[i], Longitude: st.Data2[i]}
+			}
+			if st, ok := byType["altitude"]; ok && i < len(st.Data) {
+				v := st.Data[i]
+				tp.AltitudeMeters = &v
+			}
+			if st, ok := byType["distance"]; ok && i < len(st.Data) {
+				lastDistance = st.Data[i]
+				v := distanceOffset + lastDistance
+				tp.DistanceMeters = &v
+			}
+			if st, ok := byType["heartrate"]; ok && i < len(st.Data) && st.Data[i] > 0 {
+				tp.HeartRate = &tcxValue{Value: int(st.Data[i])}
+			}
+			if st, ok := byType["cadence"]; ok && i < len(st.Data) {
+				v := int(st.Data[i])
+				tp.Cadence = &v
+			}
+			if st, ok := byType["watts"]; ok && i < len(st.Data) {
+				tp.Extensions = &tcxExtensions{TPX: tcxTPX{Watts: int(st.Data[i])}}
+			}
+			lap.Trackpoints = append(lap.Trackpoints, tp)
+		}
+
+		lap.DistanceMeters = lastDistance
+		distanceOffset += lastDistance
+		activity.Laps = append(activity.Laps, lap)
+	}
+
+	db := tcxDatabase{
+		Xmlns:      "http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2",
+		XmlnsNs3:   "http://www.garmin.com/xmlschemas/ActivityExtension/v2",
+		Activities: []tcxActivity{activity},
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(db); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Tie-breaker names accepted in the tie_breakers config list
+const (
+	TieLatestUpdated     = "latest_updated"
+	TieLatestCreated     = "latest_created"
+	TieEarliestCreated   = "earliest_created"
+	TieLongestMovingTime = "longest_moving_time"
+	TieMostStreams       = "most_streams"
+	TieDevicePriority    = "device_priority"
+	TieSourcePriority    = "source_priority"
+	TieLowestID          = "lowest_id"
+)
+
+// defaultTieBreakers preserves the original ordering: most recently touched wins
+var defaultTieBreakers = []string{TieLatestUpdated, TieLatestCreated}
+
+// tieBreakerFunc returns a negative number when a should rank before b,
+// positive when b should, and zero when it cannot decide
+type tieBreakerFunc func(s *ScoringEngine, a, b *ActivityDetail) int
+
+var tieBreakerFuncs = map[string]tieBreakerFunc{
+	TieLatestUpdated: func(_ *ScoringEngine, a, b *ActivityDetail) int {
+		return b.Updated.Compare(a.Updated.Time)
+	},
+	TieLatestCreated: func(_ *ScoringEngine, a, b *ActivityDetail) int {
+		return b.CreatedAt.Compare(a.CreatedAt.Time)
+	},
+	TieEarliestCreated: func(_ *ScoringEngine, a, b *ActivityDetail) int {
+		return a.CreatedAt.Compare(b.CreatedAt.Time)
+	},
+	TieLongestMovingTime: func(_ *ScoringEngine, a, b *ActivityDetail) int {
+		return b.MovingTime - a.MovingTime
+	},
+	TieMostStreams: func(_ *ScoringEngine, a, b *ActivityDetail) int {
+		return len(b.StreamTypes) - len(a.StreamTypes)
+	},
+	TieDevicePriority: func(s *ScoringEngine, a, b *ActivityDetail) int {
+		rank := func(d *ActivityDetail) int {
+			return priorityIndex(s.Config.DevicePriority, d.DeviceName, d.Source, d.PowerMeter, d.OAuthClientName)
+		}
+		return rank(a) - rank(b)
+	},
+	TieSourcePriority: func(s *ScoringEngine, a, b *ActivityDetail) int {
+		rank := func(d *ActivityDetail) int {
+			return priorityIndex(s.Config.SourcePriority, d.Source, d.OAuthClientName)
+		}
+		return rank(a) - rank(b)
+	},
+	TieLowestID: func(_ *ScoringEngine, a, b *ActivityDetail) int {
+		// IDs look like "i12345"; compare numerically when possible
+		na, errA := strconv.ParseInt(strings.TrimLeft(a.ID, "i"), 10, 64)
+		nb, errB := strconv.ParseInt(strings.TrimLeft(b.ID, "i"), 10, 64)
+		if errA == nil && errB == nil {
+			switch {
+			case na < nb:
+				return -1
+			case na > nb:
+				return 1
+			}
+			return 0
+		}
+		return strings.Compare(a.ID, b.ID)
+	},
+}
+
+// priorityIndex returns the position of the first list entry found in any of
+// the fields, or len(list) when none match
+func priorityIndex(list []string, fields ...string) int {
+	search := strings.ToLower(strings.Join(fields, " "))
+	for i, preferred := range list {
+		if strings.Contains(search, strings.ToLower(preferred)) {
+			return i
+		}
+	}
+	return len(list)
+}
+
+// ValidateTieBreakers checks every configured tie-breaker is known
+func ValidateTieBreakers(names []string) error {
+	for _, name := range names {
+		if _, ok := tieBreakerFuncs[name]; !ok {
+			return fmt.Errorf("unknown tie-breaker %q", name)
+		}
+	}
+	return nil
+}
+
+// tieBreakers returns the configured chain, or the default
+func (s *ScoringEngine) tieBreakers() []string {
+	if s.Config != nil && len(s.Config.TieBreakers) > 0 {
+		return s.Config.TieBreakers
+	}
+	return defaultTieBreakers
+}
+
+// Compare orders two evaluated activities: by total score, then the tie-breaker
+// chain. It returns the ordering (negative when a ranks first) and what decided it:
+// "score", a tie-breaker name, or "" when nothing separated them.
+func (s *ScoringEngine) Compare(a, b *evaluatedActivity) (int, string) {
+	if a.Score.Total != b.Score.Total {
+		if a.Score.Total > b.Score.Total {
+			return -1, "score"
+		}
+		return 1, "score"
+	}
+	for _, name := range s.tieBreakers() {
+		if c := tieBreakerFuncs[name](s, &a.Detail, &b.Detail); c != 0 {
+			return c, name
+		}
+	}
+	return 0, ""
+}
+
+// DecidingFactor names what ranked the winner above the runner-up
+func (s *ScoringEngine) DecidingFactor(winner, runnerUp *evaluatedActivity) string {
+	_, factor := s.Compare(winner, runnerUp)
+	if factor == "" {
+		return "none (full tie)"
+	}
+	return factor
+}
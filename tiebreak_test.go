@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRankDetailsTieBreakers(t *testing.T) {
+	older := IntervalsTime{time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)}
+	newer := IntervalsTime{time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)}
+
+	// Both score 2: one from notes, the other from device priority
+	details := []ActivityDetail{
+		{Activity: Activity{ID: "i200", DeviceName: "Phone", Description: "notes", CreatedAt: older, Updated: newer, MovingTime: 3000}},
+		{Activity: Activity{ID: "i100", DeviceName: "Wahoo ELEMNT", CreatedAt: newer, Updated: older, MovingTime: 3600}},
+	}
+
+	tests := []struct {
+		chain      []string
+		wantWinner string
+		wantFactor string
+	}{
+		{nil, "i200", TieLatestUpdated},
+		{[]string{TieEarliestCreated}, "i200", TieEarliestCreated},
+		{[]string{TieLongestMovingTime}, "i100", TieLongestMovingTime},
+		{[]string{TieMostStreams, TieDevicePriority}, "i100", TieDevicePriority},
+		{[]string{TieLowestID}, "i100", TieLowestID},
+	}
+
+	for _, tt := range tests {
+		s := NewScoringEngine(&Config{
+			Weights:        Weights{Manual: 2},
+			DevicePriority: []string{"Wahoo"},
+			TieBreakers:    tt.chain,
+		})
+		ranked := rankDetails(s, details)
+		if ranked[0].Detail.ID != tt.wantWinner {
+			t.Errorf("chain %v: winner = %s; want %s", tt.chain, ranked[0].Detail.ID, tt.wantWinner)
+		}
+		if got := s.DecidingFactor(&ranked[0], &ranked[1]); got != tt.wantFactor {
+			t.Errorf("chain %v: deciding factor = %q; want %q", tt.chain, got, tt.wantFactor)
+		}
+	}
+}
+
+func TestValidateTieBreakers(t *testing.T) {
+	if err := ValidateTieBreakers([]string{TieLowestID, TieSourcePriority}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateTieBreakers([]string{"coin_flip"}); err == nil {
+		t.Error("expected error for unknown tie-breaker")
+	}
+}
@@ -0,0 +1,463 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ANSI sequences used by the review TUI
+const (
+	ansiClear        = "\x1b[H\x1b[2J"
+	ansiAltScreen    = "\x1b[?1049h\x1b[?25l"
+	ansiMainScreen   = "\x1b[?25h\x1b[?1049l"
+	ansiReverse      = "\x1b[7m"
+	ansiBold         = "\x1b[1m"
+	ansiReset        = "\x1b[0m"
+	tuiHighlightRows = 3 // Header lines highlighted in the selected member's column
+)
+
+// reviewTUI is the state of the full-screen review: a list of groups, and a
+// detail view showing one group's members side by side
+type reviewTUI struct {
+	run       *Run
+	groups    []*ReviewGroup
+	decisions []Decision
+	skipped   []bool
+
+	cursor     int  // Selected group
+	member     int  // Selected member in the detail view
+	detail     bool // Showing one group
+	confirming bool // Showing the apply summary
+	status     string
+
+	width, height int
+}
+
+func newReviewTUI(run *Run, groups []*ReviewGroup) *reviewTUI {
+	t := &reviewTUI{run: run, groups: groups, skipped: make([]bool, len(groups)), width: 80, height: 24}
+	for _, g := range groups {
+		t.decisions = append(t.decisions, run.ProposeDecision(g, ""))
+	}
+	return t
+}
+
+// handleKey updates the state for one key press. It reports when the TUI should
+// exit, and whether the batch was confirmed.
+func (t *reviewTUI) handleKey(key string) (done, apply bool) {
+	t.status = ""
+	if key == "ctrl-c" {
+		return true, false
+	}
+
+	if t.confirming {
+		switch key {
+		case "y", "Y":
+			return true, true
+		default:
+			t.confirming = false
+		}
+		return false, false
+	}
+
+	if !t.detail {
+		switch key {
+		case "up", "k":
+			if t.cursor > 0 {
+				t.cursor--
+			}
+		case "down", "j":
+			if t.cursor < len(t.groups)-1 {
+				t.cursor++
+			}
+		case "enter", "right", "l":
+			if len(t.groups) > 0 {
+				t.detail, t.member = true, 0
+			}
+		case "s":
+			if len(t.groups) > 0 {
+				t.skipped[t.cursor] = !t.skipped[t.cursor]
+			}
+		case "a":
+			t.confirming = true
+		case "q", "esc":
+			return true, false
+		}
+		return false, false
+	}
+
+	g, d := t.groups[t.cursor], &t.decisions[t.cursor]
+	selected := g.Members[t.member].Detail.ID
+	switch key {
+	case "left", "h":
+		if t.member > 0 {
+			t.member--
+		}
+	case "right", "l":
+		if t.member < len(g.Members)-1 {
+			t.member++
+		}
+	case "w":
+		*d = t.run.ProposeDecision(g, selected)
+		t.skipped[t.cursor] = false
+		t.status = fmt.Sprintf("%s is now the winner; deletions and adoption were recalculated", selected)
+	case "d", " ":
+		switch {
+		case selected == d.WinnerID:
+			t.status = "The winner can't be deleted"
+		case t.run.Protector.Check(&g.Members[t.member].Detail.Activity) != "":
+			t.status = fmt.Sprintf("%s is protected", selected)
+		default:
+			d.Delete[selected] = !d.Delete[selected]
+		}
+	case "1", "2", "3", "4":
+		field := adoptableFields[key[0]-'1']
+		cycleAdoption(g, d, field)
+	case "s":
+		t.skipped[t.cursor] = !t.skipped[t.cursor]
+	case "n", "down", "j":
+		if t.cursor < len(t.groups)-1 {
+			t.cursor, t.member = t.cursor+1, 0
+		}
+	case "p", "up", "k":
+		if t.cursor > 0 {
+			t.cursor, t.member = t.cursor-1, 0
+		}
+	case "esc", "q", "b":
+		t.detail = false
+	}
+	return false, false
+}
+
+// cycleAdoption moves a winner field to the next member offering a value, and
+// back to the winner's own value after the last one
+func cycleAdoption(g *ReviewGroup, d *Decision, field string) {
+	sources := []string{""}
+	for _, m := range g.Members {
+		if m.Detail.ID == d.WinnerID {
+			continue
+		}
+		if _, ok := fieldValue(&m.Detail, field); ok {
+			sources = append(sources, m.Detail.ID)
+		}
+	}
+
+	next := ""
+	for i, id := range sources {
+		if id == d.Sources[field] {
+			next = sources[(i+1)%len(sources)]
+		}
+	}
+	if next == "" {
+		delete(d.Updates, field)
+		delete(d.Sources, field)
+		return
+	}
+	d.Updates[field], _ = fieldValue(&g.Member(next).Detail, field)
+	d.Sources[field] = next
+}
+
+// changes describes what applying a group's decision would do
+func changes(d Decision) []string {
+	var out []string
+	for _, field := range adoptableFields {
+		if v, ok := d.Updates[field]; ok {
+			out = append(out, fmt.Sprintf("set %s of %s to %q (from %s)", field, d.WinnerID, truncate(fmt.Sprint(v), 40), d.Sources[field]))
+		}
+	}
+	var deletes []string
+	for id, del := range d.Delete {
+		if del {
+			deletes = append(deletes, id)
+		}
+	}
+	sort.Strings(deletes)
+	for _, id := range deletes {
+		out = append(out, "delete "+id)
+	}
+	return out
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	if n <= 1 {
+		return string([]rune(s)[:n])
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
+
+// pad truncates or right-pads s to exactly n runes
+func pad(s string, n int) string {
+	s = truncate(s, n)
+	return s + strings.Repeat(" ", n-utf8.RuneCountInString(s))
+}
+
+// render draws the current screen as lines no wider than the terminal
+func (t *reviewTUI) render() []string {
+	switch {
+	case t.confirming:
+		return t.renderConfirm()
+	case t.detail:
+		return t.renderDetail()
+	default:
+		return t.renderList()
+	}
+}
+
+func (t *reviewTUI) header() string {
+	mode := ""
+	if t.run.DryRun {
+		mode = " [DRY RUN]"
+	}
+	return ansiBold + pad(fmt.Sprintf("intervals-deduper review · %d groups%s", len(t.groups), mode), t.width) + ansiReset
+}
+
+func (t *reviewTUI) footer(lines []string, help string) []string {
+	for len(lines) < t.height-2 {
+		lines = append(lines, "")
+	}
+	lines = lines[:t.height-2]
+	return append(lines, pad(t.status, t.width), ansiReverse+pad(help, t.width)+ansiReset)
+}
+
+func (t *reviewTUI) renderList() []string {
+	lines := []string{t.header(), ""}
+	if len(t.groups) == 0 {
+		lines = append(lines, "No duplicate groups found.")
+	}
+
+	// Scroll so the cursor stays visible
+	visible := t.height - 5
+	first := 0
+	if t.cursor >= visible {
+		first = t.cursor - visible + 1
+	}
+	for i := first; i < len(t.groups) && i < first+visible; i++ {
+		g, d := t.groups[i], t.decisions[i]
+		winner := g.Member(d.WinnerID)
+		summary := fmt.Sprintf("%d change(s)", len(changes(d)))
+		if t.skipped[i] {
+			summary = "skipped"
+		}
+		line := pad(fmt.Sprintf("  %s  %d recordings  winner: %s (%s)  %s",
+			g.Start.Format("2006-01-02 15:04"), len(g.Members), winner.Detail.Name, winner.Detail.DeviceName, summary), t.width)
+		if i == t.cursor {
+			line = ansiReverse + line + ansiReset
+		}
+		lines = append(lines, line)
+	}
+	return t.footer(lines, "↑/↓ move · enter open · s skip · a apply all · q quit")
+}
+
+func (t *reviewTUI) renderDetail() []string {
+	g, d := t.groups[t.cursor], t.decisions[t.cursor]
+	report := t.run.DescribeDecision(g, d)
+
+	title := fmt.Sprintf("Group %d/%d · %s · %d recordings", t.cursor+1, len(t.groups), g.Start.Format("2006-01-02 15:04"), len(g.Members))
+	if t.skipped[t.cursor] {
+		title += " · SKIPPED"
+	}
+	lines := []string{t.header(), pad(title, t.width), ""}
+
+	// Member columns side by side
+	colWidth := t.width/len(report.Members) - 1
+	if colWidth < 12 {
+		colWidth = 12
+	}
+	var columns [][]string
+	for _, m := range report.Members {
+		columns = append(columns, memberColumn(m, d.WinnerID))
+	}
+	rows := 0
+	for _, c := range columns {
+		rows = max(rows, len(c))
+	}
+	for row := 0; row < rows; row++ {
+		var b strings.Builder
+		for i, c := range columns {
+			cell := ""
+			if row < len(c) {
+				cell = c[row]
+			}
+			cell = pad(cell, colWidth)
+			if i == t.member && row < tuiHighlightRows {
+				cell = ansiReverse + cell + ansiReset
+			}
+			if i > 0 {
+				b.WriteString(" ")
+			}
+			b.WriteString(cell)
+		}
+		lines = append(lines, b.String())
+	}
+
+	lines = append(lines, "", ansiBold+"Winner fields"+ansiReset)
+	winner := g.Member(d.WinnerID)
+	for i, field := range adoptableFields {
+		current, _ := fieldValue(&winner.Detail, field)
+		value := fmt.Sprintf("keep %q", truncate(fmt.Sprint(current), 30))
+		if v, ok := d.Updates[field]; ok {
+			value = fmt.Sprintf("%q from %s", truncate(fmt.Sprint(v), 30), d.Sources[field])
+		}
+		lines = append(lines, pad(fmt.Sprintf("  [%d] %-12s %s", i+1, field, value), t.width))
+	}
+
+	return t.footer(lines, "←/→ member · w winner · d delete · 1-4 cycle field · s skip · n/p next/prev · esc back")
+}
+
+// memberColumn lays out one member's card for the detail view
+func memberColumn(m *MemberReport, winnerID string) []string {
+	label := strings.ToUpper(m.Plan)
+	if m.ID == winnerID {
+		label = "WINNER"
+	}
+	col := []string{
+		fmt.Sprintf("#%d %s %s", m.Rank, m.ID, label),
+		m.Name,
+		fmt.Sprintf("score %.1f", m.Score),
+		"device: " + m.Device,
+		"uploader: " + m.Uploader,
+		fmt.Sprintf("%s  Δ%.1f%%", formatDistance(m.Distance), m.DistDiff*100),
+		fmt.Sprintf("%s  Δ%.1f%%", formatDuration(m.MovingTime), m.TimeDiff*100),
+	}
+	if m.DistMismatch || m.TimeMismatch {
+		col = append(col, "! size mismatch")
+	}
+	if m.Protected != "" {
+		col = append(col, "! protected: "+m.Protected)
+	}
+	col = append(col, "")
+
+	var names []string
+	for name := range m.Breakdown {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		col = append(col, fmt.Sprintf("%+6.1f %s", m.Breakdown[name], name))
+	}
+	return col
+}
+
+func (t *reviewTUI) renderConfirm() []string {
+	lines := []string{t.header(), "", ansiBold + "Changes to apply" + ansiReset}
+	total := 0
+	for i, g := range t.groups {
+		c := changes(t.decisions[i])
+		if t.skipped[i] || len(c) == 0 {
+			continue
+		}
+		total += len(c)
+		lines = append(lines, "  "+g.Start.Format("2006-01-02 15:04"))
+		for _, change := range c {
+			lines = append(lines, pad("    - "+change, t.width))
+		}
+	}
+	if total == 0 {
+		lines = append(lines, "  Nothing to apply.")
+	}
+	return t.footer(lines, fmt.Sprintf("Apply %d change(s)? y to confirm, any other key to go back", total))
+}
+
+// readKey reads one key press from a terminal in raw mode
+func readKey(r *bufio.Reader) (string, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	switch b {
+	case 3:
+		return "ctrl-c", nil
+	case '\r', '\n':
+		return "enter", nil
+	case 0x1b:
+		if r.Buffered() == 0 {
+			return "esc", nil
+		}
+		if next, _ := r.ReadByte(); next != '[' {
+			return "esc", nil
+		}
+		code, _ := r.ReadByte()
+		switch code {
+		case 'A':
+			return "up", nil
+		case 'B':
+			return "down", nil
+		case 'C':
+			return "right", nil
+		case 'D':
+			return "left", nil
+		}
+		return "esc", nil
+	}
+	return string(b), nil
+}
+
+// stty runs stty against the controlling terminal
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// runReviewTUI shows the full-screen review and applies the confirmed decisions
+func runReviewTUI(run *Run, groups []*ReviewGroup) error {
+	saved, err := stty("-g")
+	if err != nil {
+		return fmt.Errorf("the review UI needs an interactive terminal: %w", err)
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return err
+	}
+
+	t := newReviewTUI(run, groups)
+	if size, err := stty("size"); err == nil {
+		fmt.Sscanf(size, "%d %d", &t.height, &t.width)
+	}
+
+	fmt.Print(ansiAltScreen)
+	apply := false
+	for {
+		fmt.Print(ansiClear + strings.Join(t.render(), "\r\n"))
+		key, err := readKey(stdin)
+		if err != nil {
+			break
+		}
+		var done bool
+		if done, apply = t.handleKey(key); done {
+			break
+		}
+	}
+	fmt.Print(ansiMainScreen)
+	stty(saved)
+
+	if !apply {
+		fmt.Println("No changes applied.")
+		return nil
+	}
+
+	var applied []*GroupReport
+	for i, g := range t.groups {
+		if t.skipped[i] || len(changes(t.decisions[i])) == 0 {
+			continue
+		}
+		fmt.Printf("\n🚩 %s (winner %s)\n", g.Start.Format("2006-01-02 15:04:05"), t.decisions[i].WinnerID)
+		for _, a := range run.ApplyDecision(g, t.decisions[i]) {
+			fmt.Printf("    %s %s: %s %s\n", a.Action, a.ActivityID, a.Status, a.Error)
+		}
+		applied = append(applied, run.DescribeDecision(g, t.decisions[i]))
+	}
+	if err := recordReview(run, "review", applied); err != nil {
+		fmt.Printf("⚠️  Failed to record run history: %v\n", err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestReviewTUI(t *testing.T) *reviewTUI {
+	t.Helper()
+	run := &Run{Config: &Config{}, Scoring: NewScoringEngine(&Config{}), Protector: mustProtector(t, ProtectConfig{IDs: []string{"i3"}})}
+	g := &ReviewGroup{Key: "i1,i2,i3", Members: []evaluatedActivity{
+		{Detail: ActivityDetail{Activity: Activity{ID: "i1", Name: "Morning Ride", Type: "Ride", Distance: 40000, MovingTime: 5400}}},
+		{Detail: ActivityDetail{Activity: Activity{ID: "i2", Name: "Hill Repeats", Type: "Ride", Distance: 40000, MovingTime: 5400, Feel: 2}}},
+		{Detail: ActivityDetail{Activity: Activity{ID: "i3", Name: "Race Day", Type: "Ride", Distance: 40000, MovingTime: 5400}}},
+	}}
+	return newReviewTUI(run, []*ReviewGroup{g})
+}
+
+func pressKeys(tui *reviewTUI, keys ...string) (done, apply bool) {
+	for _, k := range keys {
+		if done, apply = tui.handleKey(k); done {
+			return
+		}
+	}
+	return
+}
+
+func TestReviewTUIProposal(t *testing.T) {
+	tui := newTestReviewTUI(t)
+	want := []string{`set name of i1 to "Hill Repeats" (from i2)`, `set feel of i1 to "2" (from i2)`, "delete i2"}
+	if got := changes(tui.decisions[0]); !reflect.DeepEqual(got, want) {
+		t.Errorf("changes = %v; want %v", got, want)
+	}
+}
+
+func TestReviewTUISwapWinnerAndToggle(t *testing.T) {
+	tui := newTestReviewTUI(t)
+
+	// Open the group, select i2, make it the winner, then keep i1 and try to
+	// delete the protected i3
+	pressKeys(tui, "enter", "right", "w", "left", "d", "right", "right", "d")
+	d := tui.decisions[0]
+	if d.WinnerID != "i2" {
+		t.Fatalf("WinnerID = %s; want i2", d.WinnerID)
+	}
+	if d.Delete["i1"] || d.Delete["i3"] {
+		t.Errorf("Delete = %v; want nothing deleted", d.Delete)
+	}
+	if !strings.Contains(tui.status, "protected") {
+		t.Errorf("status = %q; want protected warning", tui.status)
+	}
+
+	// The winner's descriptive name stays; cycling offers the other members' names
+	if _, ok := d.Updates["name"]; ok {
+		t.Errorf("unexpected name adoption: %v", d.Updates)
+	}
+	pressKeys(tui, "1")
+	if d.Sources["name"] != "i1" {
+		t.Errorf("name source = %q; want i1", d.Sources["name"])
+	}
+	pressKeys(tui, "1", "1")
+	if _, ok := d.Updates["name"]; ok {
+		t.Errorf("name adoption should cycle back to the winner's own name: %v", d.Updates)
+	}
+}
+
+func TestReviewTUIConfirm(t *testing.T) {
+	tui := newTestReviewTUI(t)
+	if done, apply := pressKeys(tui, "a", "n"); done || apply {
+		t.Fatal("declining the summary should return to the list")
+	}
+	if strings.Join(tui.render(), "\n") == "" || tui.confirming {
+		t.Fatal("expected to be back on the list")
+	}
+	if done, apply := pressKeys(tui, "a", "y"); !done || !apply {
+		t.Errorf("done, apply = %v, %v; want true, true", done, apply)
+	}
+}
+
+func TestReviewTUIRenderWidth(t *testing.T) {
+	tui := newTestReviewTUI(t)
+	tui.width, tui.height = 60, 30
+	pressKeys(tui, "enter")
+
+	lines := tui.render()
+	if len(lines) != 30 {
+		t.Errorf("rendered %d lines; want 30", len(lines))
+	}
+	for _, line := range lines {
+		plain := line
+		for _, code := range []string{ansiReverse, ansiBold, ansiReset} {
+			plain = strings.ReplaceAll(plain, code, "")
+		}
+		if n := len([]rune(plain)); n > 60 {
+			t.Errorf("line wider than terminal (%d): %q", n, plain)
+		}
+	}
+}
+
+func TestReadKey(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("\x1b[Aw\r\x03"))
+	var keys []string
+	for {
+		k, err := readKey(r)
+		if err != nil {
+			break
+		}
+		keys = append(keys, k)
+	}
+	if want := []string{"up", "w", "enter", "ctrl-c"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys = %v; want %v", keys, want)
+	}
+}
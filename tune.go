@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+)
+
+const (
+	// tuneTemperature scales score margins (in points) into log-odds
+	tuneTemperature = 5.0
+	// tuneRegularization pulls fitted weights towards the current config
+	tuneRegularization = 0.01
+	tuneIterations     = 5000
+	tuneLearningRate   = 2.0
+)
+
+// TrainingSample is a winner-vs-loser comparison and the user's answer
+type TrainingSample struct {
+	Diff   map[string]float64 // Winner features minus loser features
+	Offset float64            // Winner fixed score minus loser fixed score
+	Label  float64            // 1 when the user confirmed deleting the loser, 0 when they overrode the winner
+}
+
+// SamplesFromDecisions turns winner overrides and accepted deletions into
+// training samples. A declined deletion says nothing about the ranking (the
+// user may keep both recordings for other reasons), so it is left out.
+func SamplesFromDecisions(records []DecisionRecord) []TrainingSample {
+	var samples []TrainingSample
+	for i := range records {
+		r := &records[i]
+		// A winner override is a declined "winner beats loser" answer
+		if r.Kind != "winner" && !(r.Kind == "delete" && r.Accepted) {
+			continue
+		}
+		winner, loser := r.Member(r.WinnerID), r.Member(r.LoserID)
+		if winner == nil || loser == nil {
+			continue
+		}
+
+		sample := TrainingSample{
+			Diff:   make(map[string]float64),
+			Offset: winner.Fixed - loser.Fixed,
+		}
+		for k, v := range winner.Features {
+			sample.Diff[k] += v
+		}
+		for k, v := range loser.Features {
+			sample.Diff[k] -= v
+		}
+		if r.Accepted {
+			sample.Label = 1
+		}
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+// margin is how far the winner outscores the loser under the given weights
+func (s TrainingSample) margin(w Weights) float64 {
+	return w.Apply(s.Diff) + s.Offset
+}
+
+// Agreement returns the fraction of samples where the weights reproduce the answer
+func Agreement(samples []TrainingSample, w Weights) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	agree := 0
+	for _, s := range samples {
+		predicted := s.margin(w) > 0
+		if predicted == (s.Label == 1) {
+			agree++
+		}
+	}
+	return float64(agree) / float64(len(samples))
+}
+
+// FitWeights fits weights by logistic regression on the score margin, starting
+// from (and regularized towards) the current weights. Weights never go negative.
+func FitWeights(samples []TrainingSample, start Weights) Weights {
+	fitted := start
+	if len(samples) == 0 {
+		return fitted
+	}
+
+	startFields := start.weightFields()
+	fields := fitted.weightFields()
+	n := float64(len(samples))
+
+	for iter := 0; iter < tuneIterations; iter++ {
+		grad := make([]float64, len(fields))
+		for _, s := range samples {
+			p := 1 / (1 + math.Exp(-s.margin(fitted)/tuneTemperature))
+			for i, f := range fields {
+				grad[i] += (p - s.Label) * s.Diff[f.Name] / tuneTemperature / n
+			}
+		}
+		for i, f := range fields {
+			grad[i] += 2 * tuneRegularization * (*f.Value - *startFields[i].Value) / tuneTemperature
+			*f.Value = math.Max(0, *f.Value-tuneLearningRate*grad[i])
+		}
+	}
+
+	for _, f := range fields {
+		*f.Value = math.Round(*f.Value*10) / 10
+	}
+	return fitted
+}
+
+// runTune fits weights to the recorded interactive decisions and prints the
+// suggested config change
+func runTune(config *Config) {
+	records, err := LoadDecisions(config.DecisionsFile())
+	if err != nil {
+		log.Fatalf("Error loading decisions: %v", err)
+	}
+	samples := SamplesFromDecisions(records)
+	if len(samples) == 0 {
+		fmt.Printf("No winner overrides or accepted deletions recorded in %s yet. Run with --interactive to collect some.\n", config.DecisionsFile())
+		return
+	}
+
+	fitted := FitWeights(samples, config.Weights)
+	before := Agreement(samples, config.Weights)
+	after := Agreement(samples, fitted)
+
+	fmt.Printf("📈 Fitted weights to %d recorded decisions\n", len(samples))
+	fmt.Printf("   Agreement: current %.0f%% → suggested %.0f%%\n\n", before*100, after*100)
+
+	current := config.Weights
+	currentFields := current.weightFields()
+	changed := false
+	fmt.Println(" weights:")
+	for i, f := range fitted.weightFields() {
+		old := *currentFields[i].Value
+		if old == *f.Value {
+			fmt.Printf("   %s: %g\n", f.Name, old)
+			continue
+		}
+		changed = true
+		fmt.Printf("-  %s: %g\n", f.Name, old)
+		fmt.Printf("+  %s: %g\n", f.Name, *f.Value)
+	}
+	if !changed {
+		fmt.Println("\n✅ Current weights already reproduce your decisions as well as the fit can.")
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import "testing"
+
+func TestFitWeights(t *testing.T) {
+	// The user consistently keeps the recording with heart rate, even when the
+	// other one has GPS, so GPS is overweighted relative to heart rate.
+	member := func(id string, features map[string]float64) DecisionMember {
+		return DecisionMember{ID: id, Features: features}
+	}
+	var records []DecisionRecord
+	for i := 0; i < 5; i++ {
+		records = append(records,
+			DecisionRecord{
+				Kind: "winner", Accepted: false, WinnerID: "gps", LoserID: "hr",
+				Members: []DecisionMember{
+					member("gps", map[string]float64{"gps": 1}),
+					member("hr", map[string]float64{"heartrate": 1}),
+				},
+			},
+			DecisionRecord{
+				Kind: "delete", Accepted: true, WinnerID: "both", LoserID: "gps",
+				Members: []DecisionMember{
+					member("both", map[string]float64{"gps": 1, "heartrate": 1}),
+					member("gps", map[string]float64{"gps": 1}),
+				},
+			},
+			DecisionRecord{Kind: "name", Accepted: true, WinnerID: "gps"},
+			// Declining a deletion doesn't say the ranking was wrong
+			DecisionRecord{
+				Kind: "delete", Accepted: false, WinnerID: "gps", LoserID: "hr",
+				Members: []DecisionMember{
+					member("gps", map[string]float64{"gps": 1}),
+					member("hr", map[string]float64{"heartrate": 1}),
+				},
+			},
+		)
+	}
+
+	samples := SamplesFromDecisions(records)
+	if len(samples) != 10 {
+		t.Fatalf("expected 10 samples, got %d", len(samples))
+	}
+
+	start := Weights{GPS: 10, HeartRate: 5}
+	if got := Agreement(samples, start); got != 0.5 {
+		t.Errorf("starting agreement = %.2f; want 0.50", got)
+	}
+
+	fitted := FitWeights(samples, start)
+	if got := Agreement(samples, fitted); got != 1 {
+		t.Errorf("fitted agreement = %.2f; want 1.00 (weights %+v)", got, fitted)
+	}
+	if fitted.HeartRate <= fitted.GPS {
+		t.Errorf("expected heart rate to outweigh GPS, got %+v", fitted)
+	}
+}
+
+func TestSamplesFromWinnerOverride(t *testing.T) {
+	records := []DecisionRecord{{
+		Kind: "winner", Accepted: false, WinnerID: "gps", LoserID: "hr",
+		Members: []DecisionMember{
+			{ID: "gps", Features: map[string]float64{"gps": 1}},
+			{ID: "hr", Features: map[string]float64{"heartrate": 1}},
+		},
+	}}
+
+	samples := SamplesFromDecisions(records)
+	if len(samples) != 1 {
+		t.Fatalf("expected 1 sample, got %d", len(samples))
+	}
+	if s := samples[0]; s.Label != 0 || s.Diff["gps"] != 1 || s.Diff["heartrate"] != -1 {
+		t.Errorf("unexpected sample: %+v", s)
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultWatchInterval   = 60 * time.Minute
+	defaultWatchStateFile  = "watch_state.json"
+	defaultWatchHealthAddr = ":8081"
+)
+
+// WatchConfig controls the resident watch mode
+type WatchConfig struct {
+	IntervalMinutes int    `yaml:"interval_minutes"` // Time between scans (default 60)
+	StateFile       string `yaml:"state_file"`       // Activities seen by previous scans (default watch_state.json)
+	HealthAddr      string `yaml:"health_addr"`      // Listen address for /healthz (default :8081, "off" to disable)
+}
+
+// Interval returns the configured time between scans, or the default
+func (c WatchConfig) Interval() time.Duration {
+	if c.IntervalMinutes > 0 {
+		return time.Duration(c.IntervalMinutes) * time.Minute
+	}
+	return defaultWatchInterval
+}
+
+// StatePath returns the configured state file, or the default
+func (c WatchConfig) StatePath() string {
+	if c.StateFile != "" {
+		return c.StateFile
+	}
+	return defaultWatchStateFile
+}
+
+// Addr returns the health endpoint address, or "" when disabled
+func (c WatchConfig) Addr() string {
+	switch c.HealthAddr {
+	case "":
+		return defaultWatchHealthAddr
+	case "off":
+		return ""
+	}
+	return c.HealthAddr
+}
+
+// WatchState remembers the activities seen by the last scan
+type WatchState struct {
+	LastRun time.Time            `json:"last_run"`
+	Seen    map[string]time.Time `json:"seen"` // Activity ID -> last updated time
+
+	path string
+}
+
+// LoadWatchState reads the state file. A missing file yields an empty state,
+// so the first scan processes every activity in the window.
+func LoadWatchState(path string) (*WatchState, error) {
+	state := &WatchState{Seen: make(map[string]time.Time), path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return state, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if state.Seen == nil {
+		state.Seen = make(map[string]time.Time)
+	}
+	return state, nil
+}
+
+// Save writes the state back to disk
+func (s *WatchState) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
+
+// Changed returns the activities that are new or were updated since the last scan
+func (s *WatchState) Changed(activities []Activity) map[string]bool {
+	changed := make(map[string]bool)
+	for _, a := range activities {
+		if seen, ok := s.Seen[a.ID]; !ok || !seen.Equal(a.Updated.Time) {
+			changed[a.ID] = true
+		}
+	}
+	return changed
+}
+
+// Record replaces the seen activities with the current window, leaving out
+// activities deleted by the scan and those that couldn't be processed, so the
+// next scan retries their cluster
+func (s *WatchState) Record(activities []Activity, deleted, failed map[string]bool, at time.Time) {
+	s.Seen = make(map[string]time.Time)
+	for _, a := range activities {
+		if !deleted[a.ID] && !failed[a.ID] {
+			s.Seen[a.ID] = a.Updated.Time
+		}
+	}
+	s.LastRun = at
+}
+
+// relatedActivities returns every activity in a cluster (activities overlapping
+// or within gap of each other) that contains a changed activity, so duplicate
+// groups and split recordings are always processed whole
+func relatedActivities(activities []Activity, changed map[string]bool, gap time.Duration) []Activity {
+	sorted := make([]Activity, len(activities))
+	copy(sorted, activities)
+	sort.Slice(sorted, func(i, j int) bool {
+		return activityStart(&sorted[i]).Before(activityStart(&sorted[j]))
+	})
+
+	var related, cluster []Activity
+	var clusterEnd time.Time
+	dirty := false
+	flush := func() {
+		if dirty {
+			related = append(related, cluster...)
+		}
+		cluster, dirty = nil, false
+	}
+	for _, a := range sorted {
+		if len(cluster) > 0 && activityStart(&a).Sub(clusterEnd) > gap {
+			flush()
+		}
+		if len(cluster) == 0 || activityEnd(&a).After(clusterEnd) {
+			clusterEnd = activityEnd(&a)
+		}
+		cluster = append(cluster, a)
+		dirty = dirty || changed[a.ID]
+	}
+	flush()
+	return related
+}
+
+// watchHealth is the status reported by /healthz
+type watchHealth struct {
+	mu          sync.Mutex
+	Status      string    `json:"status"` // "starting", "ok" or "error"
+	LastRun     time.Time `json:"last_run,omitzero"`
+	LastSuccess time.Time `json:"last_success,omitzero"`
+	LastError   string    `json:"last_error,omitempty"`
+	NextRun     time.Time `json:"next_run,omitzero"`
+	Scans       int       `json:"scans"`
+	Processed   int       `json:"processed"` // Activities processed by the last scan
+}
+
+func (h *watchHealth) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if h.Status == "error" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(h)
+}
+
+func (h *watchHealth) update(f func(h *watchHealth)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	f(h)
+}
+
+// watchScan lists the rolling window and processes the clusters that contain
+// new or updated activities
+func watchScan(config *Config, client *IntervalsClient, output OutputWriter, progress io.Writer, dryRun bool, state *WatchState) (int, error) {
+	newest := time.Now()
+	oldest := newest.AddDate(0, 0, -config.DaysToSync)
+	fmt.Fprintf(progress, "\n🔍 [%s] Scanning %s to %s...\n", newest.Format("2006-01-02 15:04:05"), oldest.Format("2006-01-02"), newest.Format("2006-01-02"))
+
+	activities, err := client.ListActivities(oldest, newest)
+	if err != nil {
+		return 0, fmt.Errorf("fetching activities: %w", err)
+	}
+
+	changed := state.Changed(activities)
+	related := relatedActivities(activities, changed, max(config.Splits.MaxGap(), 30*time.Second))
+	fmt.Fprintf(progress, "   %d activities, %d new or updated, %d to process\n", len(activities), len(changed), len(related))
+
+	run, err := NewRun(config, client, dryRun, false)
+	if err != nil {
+		return 0, err
+	}
+	run.Output = output
+	run.Progress = progress
+	run.Report.Oldest, run.Report.Newest = oldest, newest
+	if len(related) > 0 {
+		run.Process(related)
+	}
+
+	state.Record(activities, run.Deleted, run.Failed, newest)
+	if err := state.Save(); err != nil {
+		return len(related), fmt.Errorf("saving watch state: %w", err)
+	}
+	return len(related), nil
+}
+
+// runWatch scans every athlete on an interval until interrupted, serving
+// /healthz meanwhile
+func runWatch(config *Config, athletes []*Config, output OutputWriter, progress io.Writer, dryRun bool) {
+	states := make([]*WatchState, len(athletes))
+	for i, a := range athletes {
+		state, err := LoadWatchState(a.Watch.StatePath())
+		if err != nil {
+			log.Fatalf("Error loading watch state: %v", err)
+		}
+		states[i] = state
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	health := &watchHealth{Status: "starting"}
+	metrics := NewMetrics()
+	output = metricsOutput{output, metrics}
+	if addr := config.Watch.Addr(); addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("GET /healthz", health)
+		mux.Handle("GET /metrics", metrics)
+		server := &http.Server{Addr: addr, Handler: mux}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatalf("Error serving health endpoint: %v", err)
+			}
+		}()
+		defer server.Shutdown(context.Background())
+		fmt.Fprintf(progress, "💓 Health endpoint on http://%s/healthz, metrics on /metrics\n", addr)
+	}
+
+	interval := config.Watch.Interval()
+	fmt.Fprintf(progress, "👀 Watching %d athlete(s) every %s\n", len(athletes), interval)
+	for {
+		processed := 0
+		var errs []error
+		for i, a := range athletes {
+			if len(athletes) > 1 {
+				fmt.Fprintf(progress, "\n👤 Athlete %s\n", a.Label())
+			}
+			client := NewIntervalsClient(a.APIKey, a.AthleteID)
+			metrics.Instrument(client)
+			n, err := watchScan(a, client, output, progress, dryRun, states[i])
+			processed += n
+			if err != nil {
+				metrics.RecordRunError(a.AthleteID)
+				fmt.Fprintf(progress, "❌ Scan failed for %s: %v\n", a.Label(), err)
+				errs = append(errs, fmt.Errorf("%s: %w", a.Label(), err))
+			}
+		}
+		output.Flush()
+
+		now := time.Now()
+		err := errors.Join(errs...)
+		health.update(func(h *watchHealth) {
+			h.Scans++
+			h.LastRun = now
+			h.NextRun = now.Add(interval)
+			h.Processed = processed
+			if err != nil {
+				h.Status, h.LastError = "error", err.Error()
+			} else {
+				h.Status, h.LastError, h.LastSuccess = "ok", "", now
+			}
+		})
+
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(progress, "👋 Stopping watch")
+			return
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRelatedActivities(t *testing.T) {
+	at := func(clock string) IntervalsTime {
+		ts, _ := time.Parse("15:04", clock)
+		return IntervalsTime{Time: ts}
+	}
+	activities := []Activity{
+		{ID: "a", StartDate: at("07:00"), ElapsedTime: 3600},
+		{ID: "b", StartDate: at("07:00"), ElapsedTime: 3500},
+		{ID: "c", StartDate: at("08:05"), ElapsedTime: 600}, // 5 minutes after a ends
+		{ID: "d", StartDate: at("12:00"), ElapsedTime: 600},
+		{ID: "e", StartDate: at("18:00"), ElapsedTime: 600},
+	}
+
+	related := relatedActivities(activities, map[string]bool{"c": true, "e": true}, 10*time.Minute)
+
+	var ids []string
+	for _, a := range related {
+		ids = append(ids, a.ID)
+	}
+	if want := []string{"a", "b", "c", "e"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("related = %v; want %v", ids, want)
+	}
+}
+
+func TestWatchStateChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	state, err := LoadWatchState(path)
+	if err != nil {
+		t.Fatalf("LoadWatchState error: %v", err)
+	}
+
+	updated := IntervalsTime{Time: time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)}
+	activities := []Activity{{ID: "i1", Updated: updated}, {ID: "i2", Updated: updated}}
+	state.Record(activities, map[string]bool{"i2": true}, nil, time.Now())
+	if err := state.Save(); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+
+	reloaded, err := LoadWatchState(path)
+	if err != nil {
+		t.Fatalf("LoadWatchState error: %v", err)
+	}
+	activities = append(activities, Activity{ID: "i3", Updated: updated})
+	activities[0].Updated = IntervalsTime{Time: updated.Add(time.Minute)}
+
+	want := map[string]bool{"i1": true, "i2": true, "i3": true}
+	if got := reloaded.Changed(activities); !reflect.DeepEqual(got, want) {
+		t.Errorf("Changed = %v; want %v", got, want)
+	}
+	activities[0].Updated = updated
+	if got := reloaded.Changed(activities[:1]); len(got) != 0 {
+		t.Errorf("unchanged activity reported as changed: %v", got)
+	}
+}
+
+func TestWatchScan(t *testing.T) {
+	var calls []string
+	server := newFakeIntervals(t, &calls)
+	defer server.Close()
+
+	run := newTestRun(t, server, false)
+	config := run.Config
+	config.DaysToSync = 30
+	state, _ := LoadWatchState(filepath.Join(t.TempDir(), "state.json"))
+
+	processed, err := watchScan(config, run.Client, &humanOutput{}, io.Discard, false, state)
+	if err != nil || processed != 2 {
+		t.Fatalf("first scan processed %d (err %v); want 2", processed, err)
+	}
+	if strings.Join(calls, ",") != "DELETE i2" {
+		t.Errorf("calls = %v; want [DELETE i2]", calls)
+	}
+
+	processed, err = watchScan(config, run.Client, &humanOutput{}, io.Discard, false, state)
+	if err != nil || processed != 0 {
+		t.Errorf("second scan processed %d (err %v); want 0", processed, err)
+	}
+}
+
+func TestWatchScanRetriesFailedGroups(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string // The first request for i2 with this method fails
+	}{
+		{"detail fetch", "GET"},
+		{"delete", "DELETE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			api := newFakeIntervals(t, &calls)
+			defer api.Close()
+
+			failed := false
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == tt.method && r.URL.Path == "/api/v1/activity/i2" && !failed {
+					failed = true
+					http.Error(w, "unavailable", http.StatusServiceUnavailable)
+					return
+				}
+				api.Config.Handler.ServeHTTP(w, r)
+			}))
+			defer server.Close()
+
+			run := newTestRun(t, server, false)
+			config := run.Config
+			config.DaysToSync = 30
+			state, _ := LoadWatchState(filepath.Join(t.TempDir(), "state.json"))
+
+			if _, err := watchScan(config, run.Client, &humanOutput{}, io.Discard, false, state); err != nil {
+				t.Fatalf("first scan error: %v", err)
+			}
+			if _, seen := state.Seen["i2"]; seen || len(calls) != 0 {
+				t.Fatalf("calls = %v, seen = %v; want i2 left for the next scan", calls, state.Seen)
+			}
+
+			processed, err := watchScan(config, run.Client, &humanOutput{}, io.Discard, false, state)
+			if err != nil || processed != 2 || strings.Join(calls, ",") != "DELETE i2" {
+				t.Errorf("second scan processed %d (err %v), calls %v; want the group retried", processed, err, calls)
+			}
+		})
+	}
+}
+
+func TestWatchHealth(t *testing.T) {
+	health := &watchHealth{Status: "ok", Scans: 3}
+	rec := httptest.NewRecorder()
+	health.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != 200 || !strings.Contains(rec.Body.String(), `"scans":3`) {
+		t.Errorf("status %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	health.update(func(h *watchHealth) { h.Status, h.LastError = "error", "boom" })
+	rec = httptest.NewRecorder()
+	health.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != 503 || !strings.Contains(rec.Body.String(), "boom") {
+		t.Errorf("status %d, body %s", rec.Code, rec.Body.String())
+	}
+}
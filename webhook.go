@@ -0,0 +1,225 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWebhookAddr     = ":8082"
+	defaultWebhookDebounce = 5 * time.Minute
+	// webhookMargin widens the window around notified activities so every
+	// member of their duplicate group or split recording is listed
+	webhookMargin = time.Hour
+)
+
+// Intervals.icu webhook event types that can introduce duplicates
+var webhookTriggers = map[string]bool{
+	"ACTIVITY_UPLOADED": true,
+}
+
+// WebhookConfig controls the webhook receiver
+type WebhookConfig struct {
+	Addr            string `yaml:"addr"`             // Listen address (default :8082)
+	Secret          string `yaml:"secret"`           // Shared secret configured for the webhook in Intervals.icu
+	DebounceMinutes int    `yaml:"debounce_minutes"` // Quiet period per athlete before de-duplicating (default 5)
+}
+
+// ListenAddr returns the configured listen address, or the default
+func (c WebhookConfig) ListenAddr() string {
+	if c.Addr != "" {
+		return c.Addr
+	}
+	return defaultWebhookAddr
+}
+
+// Debounce returns the configured quiet period, or the default
+func (c WebhookConfig) Debounce() time.Duration {
+	if c.DebounceMinutes > 0 {
+		return time.Duration(c.DebounceMinutes) * time.Minute
+	}
+	return defaultWebhookDebounce
+}
+
+// webhookEvent is one notification in an Intervals.icu webhook payload
+type webhookEvent struct {
+	AthleteID string    `json:"athlete_id"`
+	Type      string    `json:"type"`
+	Timestamp string    `json:"timestamp"`
+	Activity  *Activity `json:"activity"`
+}
+
+// webhookPayload is the body Intervals.icu posts to the webhook URL
+type webhookPayload struct {
+	Secret string         `json:"secret"`
+	Events []webhookEvent `json:"events"`
+}
+
+// pendingWindow is the time window waiting to be de-duplicated for an athlete
+type pendingWindow struct {
+	oldest, newest time.Time
+	timer          *time.Timer
+}
+
+// webhookReceiver accepts notifications and runs the pipeline once an athlete's
+// uploads have been quiet for the debounce period
+type webhookReceiver struct {
+	secret   string
+	debounce time.Duration
+	process  func(athleteID string, oldest, newest time.Time)
+	progress io.Writer
+
+	mu      sync.Mutex
+	pending map[string]*pendingWindow
+	running sync.Mutex // Runs are serialized so they don't race on shared files
+}
+
+func newWebhookReceiver(secret string, debounce time.Duration, process func(athleteID string, oldest, newest time.Time)) *webhookReceiver {
+	return &webhookReceiver{secret: secret, debounce: debounce, process: process, progress: os.Stdout, pending: make(map[string]*pendingWindow)}
+}
+
+// authorized checks the shared secret from the payload, the X-Webhook-Secret
+// header or the secret query parameter
+func (wr *webhookReceiver) authorized(r *http.Request, payload *webhookPayload) bool {
+	for _, candidate := range []string{payload.Secret, r.Header.Get("X-Webhook-Secret"), r.URL.Query().Get("secret")} {
+		if candidate != "" && subtle.ConstantTimeCompare([]byte(candidate), []byte(wr.secret)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func (wr *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var payload webhookPayload
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&payload); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	if !wr.authorized(r, &payload) {
+		http.Error(w, "invalid secret", http.StatusUnauthorized)
+		return
+	}
+
+	for _, e := range payload.Events {
+		if !webhookTriggers[e.Type] || e.AthleteID == "" {
+			continue
+		}
+		oldest, newest := eventWindow(e)
+		wr.schedule(e.AthleteID, oldest, newest)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// eventWindow is the time span an event's activity covers, falling back to the
+// event time (or now) when the payload has no activity
+func eventWindow(e webhookEvent) (time.Time, time.Time) {
+	if e.Activity != nil && !e.Activity.StartDateLocal.IsZero() {
+		start := e.Activity.StartDateLocal.Time
+		seconds := max(e.Activity.ElapsedTime, e.Activity.MovingTime)
+		end := start.Add(time.Duration(seconds) * time.Second)
+		return start.Add(-webhookMargin), end.Add(webhookMargin)
+	}
+	at := time.Now()
+	if t, err := time.Parse(time.RFC3339, e.Timestamp); err == nil {
+		at = t
+	}
+	return at.Add(-24 * time.Hour), at
+}
+
+// schedule widens the athlete's pending window and restarts its debounce timer
+func (wr *webhookReceiver) schedule(athleteID string, oldest, newest time.Time) {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	p, ok := wr.pending[athleteID]
+	if !ok {
+		p = &pendingWindow{oldest: oldest, newest: newest}
+		wr.pending[athleteID] = p
+		p.timer = time.AfterFunc(wr.debounce, func() { wr.fire(athleteID) })
+		fmt.Fprintf(wr.progress, "📨 Notification for athlete %s; de-duplicating in %s\n", athleteID, wr.debounce)
+		return
+	}
+	if oldest.Before(p.oldest) {
+		p.oldest = oldest
+	}
+	if newest.After(p.newest) {
+		p.newest = newest
+	}
+	p.timer.Reset(wr.debounce)
+}
+
+// fire runs the pipeline for an athlete's pending window
+func (wr *webhookReceiver) fire(athleteID string) {
+	wr.mu.Lock()
+	p, ok := wr.pending[athleteID]
+	delete(wr.pending, athleteID)
+	wr.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	wr.running.Lock()
+	defer wr.running.Unlock()
+	wr.process(athleteID, p.oldest, p.newest)
+}
+
+// runWebhook serves the webhook receiver until the process exits
+func runWebhook(config *Config, athletes []*Config, output OutputWriter, progress io.Writer, dryRun bool) {
+	if config.Webhook.Secret == "" {
+		log.Fatalf("webhook.secret must be set in config.yml")
+	}
+
+	metrics := NewMetrics()
+	output = metricsOutput{output, metrics}
+
+	byID := make(map[string]*Config)
+	for _, a := range athletes {
+		byID[a.AthleteID] = a
+	}
+
+	receiver := newWebhookReceiver(config.Webhook.Secret, config.Webhook.Debounce(), func(athleteID string, oldest, newest time.Time) {
+		athlete, ok := byID[athleteID]
+		if !ok {
+			fmt.Fprintf(progress, "⏭️  Ignoring notification for unknown athlete %s\n", athleteID)
+			return
+		}
+		fmt.Fprintf(progress, "\n🔍 De-duplicating %s to %s for athlete %s...\n", oldest.Format("2006-01-02 15:04"), newest.Format("2006-01-02 15:04"), athlete.Label())
+		client := NewIntervalsClient(athlete.APIKey, athlete.AthleteID)
+		metrics.Instrument(client)
+		activities, err := client.ListActivities(oldest, newest)
+		if err != nil {
+			metrics.RecordRunError(athleteID)
+			fmt.Fprintf(progress, "❌ Error fetching activities: %v\n", err)
+			return
+		}
+		run, err := NewRun(athlete, client, dryRun, false)
+		if err != nil {
+			fmt.Fprintf(progress, "❌ Error: %v\n", err)
+			return
+		}
+		run.Output = output
+		run.Progress = progress
+		run.Report.Oldest, run.Report.Newest = oldest, newest
+		run.Process(activities)
+		output.Flush()
+	})
+
+	receiver.progress = progress
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /webhook", receiver)
+	mux.Handle("GET /metrics", metrics)
+	addr := config.Webhook.ListenAddr()
+	fmt.Fprintf(progress, "🪝 Listening for Intervals.icu webhooks on http://%s/webhook (debounce %s)\n", addr, config.Webhook.Debounce())
+	if err := http.ListenAndServe(addr, mux); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("Error serving webhook: %v", err)
+	}
+}
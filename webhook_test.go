@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type webhookCall struct {
+	athleteID      string
+	oldest, newest time.Time
+}
+
+func newTestReceiver(debounce time.Duration) (*webhookReceiver, chan webhookCall) {
+	calls := make(chan webhookCall, 10)
+	receiver := newWebhookReceiver("s3cret", debounce, func(athleteID string, oldest, newest time.Time) {
+		calls <- webhookCall{athleteID, oldest, newest}
+	})
+	return receiver, calls
+}
+
+func postWebhook(receiver *webhookReceiver, body string, header map[string]string) int {
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader(body))
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	receiver.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestWebhookSecret(t *testing.T) {
+	receiver, calls := newTestReceiver(time.Hour)
+	event := `"events":[{"athlete_id":"i1","type":"ACTIVITY_UPLOADED"}]`
+
+	tests := []struct {
+		name   string
+		body   string
+		header map[string]string
+		want   int
+	}{
+		{"missing", `{` + event + `}`, nil, http.StatusUnauthorized},
+		{"wrong", `{"secret":"nope",` + event + `}`, nil, http.StatusUnauthorized},
+		{"payload", `{"secret":"s3cret",` + event + `}`, nil, http.StatusNoContent},
+		{"header", `{` + event + `}`, map[string]string{"X-Webhook-Secret": "s3cret"}, http.StatusNoContent},
+		{"invalid json", `{`, nil, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := postWebhook(receiver, tt.body, tt.header); got != tt.want {
+				t.Errorf("status = %d; want %d", got, tt.want)
+			}
+		})
+	}
+	if len(calls) != 0 {
+		t.Error("nothing should run before the debounce period")
+	}
+}
+
+func TestWebhookDebounce(t *testing.T) {
+	receiver, calls := newTestReceiver(50 * time.Millisecond)
+
+	post := func(athlete, start string) {
+		body := `{"secret":"s3cret","events":[{"athlete_id":"` + athlete + `","type":"ACTIVITY_UPLOADED","activity":{"id":"x","start_date_local":"` + start + `","elapsed_time":3600}}]}`
+		if code := postWebhook(receiver, body, nil); code != http.StatusNoContent {
+			t.Fatalf("status = %d", code)
+		}
+	}
+	post("i1", "2024-06-01T07:00:00")
+	post("i2", "2024-06-01T09:00:00")
+	time.Sleep(20 * time.Millisecond)
+	post("i1", "2024-06-01T07:00:20")
+	postWebhook(receiver, `{"secret":"s3cret","events":[{"athlete_id":"i1","type":"ACTIVITY_DELETED"}]}`, nil)
+
+	got := make(map[string]webhookCall)
+	timeout := time.After(time.Second)
+	for len(got) < 2 {
+		select {
+		case c := <-calls:
+			if _, dup := got[c.athleteID]; dup {
+				t.Errorf("athlete %s processed twice", c.athleteID)
+			}
+			got[c.athleteID] = c
+		case <-timeout:
+			t.Fatalf("only %d athletes processed", len(got))
+		}
+	}
+
+	c := got["i1"]
+	wantOldest := time.Date(2024, 6, 1, 6, 0, 0, 0, time.UTC)
+	wantNewest := time.Date(2024, 6, 1, 9, 0, 20, 0, time.UTC)
+	if !c.oldest.Equal(wantOldest) || !c.newest.Equal(wantNewest) {
+		t.Errorf("window = %s - %s; want %s - %s", c.oldest, c.newest, wantOldest, wantNewest)
+	}
+
+	select {
+	case extra := <-calls:
+		t.Errorf("unexpected extra run: %+v", extra)
+	case <-time.After(100 * time.Millisecond):
+	}
+}